@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cfg "github.com/conductorone/baton-auth0/pkg/config"
+	"github.com/conductorone/baton-auth0/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/cli"
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-sdk/pkg/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// addLogStreamReceiverCommand adds the command running the HTTP server that receives
+// Auth0 webhook log streams and queues their entries for the event feed.
+func addLogStreamReceiverCommand(ctx context.Context, v *viper.Viper, cmd *cobra.Command) error {
+	schema := field.NewConfiguration(cfg.LogStreamReceiverFields)
+	_, err := cli.AddCommand(cmd, v, &schema, &cobra.Command{
+		Use:   "log-stream-receiver",
+		Short: "Receive Auth0 webhook log streams for the event feed",
+		RunE: func(c *cobra.Command, _ []string) error {
+			err := v.BindPFlags(c.Flags())
+			if err != nil {
+				return err
+			}
+			return runLogStreamReceiver(ctx, v)
+		},
+	})
+	return err
+}
+
+func runLogStreamReceiver(ctx context.Context, v *viper.Viper) error {
+	ctx, err := logging.Init(
+		ctx,
+		logging.WithLogFormat(v.GetString("log-format")),
+		logging.WithLogLevel(v.GetString("log-level")),
+	)
+	if err != nil {
+		return err
+	}
+	l := ctxzap.Extract(ctx)
+
+	authorization := v.GetString(cfg.LogStreamAuthorizationField.FieldName)
+	if authorization == "" {
+		return fmt.Errorf("baton-auth0: the log stream receiver requires %s", cfg.LogStreamAuthorizationField.FieldName)
+	}
+	queuePath := v.GetString(cfg.LogStreamQueuePathField.FieldName)
+	if queuePath == "" {
+		return fmt.Errorf("baton-auth0: the log stream receiver requires %s", cfg.LogStreamQueuePathField.FieldName)
+	}
+
+	server := &http.Server{
+		Addr:              v.GetString(cfg.LogStreamAddressField.FieldName),
+		Handler:           connector.NewLogStreamHandler(ctx, connector.NewLogStreamQueue(queuePath), authorization),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Batches being written when the receiver is stopped are finished before exiting,
+	// so that Auth0 doesn't resend batches that were already queued.
+	shutdown := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		shutdown <- server.Shutdown(shutdownCtx)
+	}()
+
+	l.Info("baton-auth0: receiving log streams", zap.String("address", server.Addr), zap.String("queue", queuePath))
+	err = server.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-shutdown
+}
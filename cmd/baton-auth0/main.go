@@ -24,7 +24,7 @@ var (
 func main() {
 	ctx := context.Background()
 
-	_, cmd, err := config.DefineConfiguration(
+	v, cmd, err := config.DefineConfiguration(
 		ctx,
 		connectorName,
 		getConnector,
@@ -37,6 +37,11 @@ func main() {
 	}
 
 	cmd.Version = version
+	err = addLogStreamReceiverCommand(ctx, v, cmd)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
 
 	err = cmd.Execute()
 	if err != nil {
@@ -51,13 +56,7 @@ func getConnector(ctx context.Context, config *cfg.Auth0) (types.ConnectorServer
 		return nil, err
 	}
 
-	cb, err := connector.New(
-		ctx,
-		config.Auth0BaseUrl,
-		config.Auth0ClientId,
-		config.Auth0ClientSecret,
-		config.SyncPermissions,
-	)
+	cb, err := connector.New(ctx, config)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
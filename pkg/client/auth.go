@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// Client assertions are used right away, so they only need to outlive the
+	// token request.
+	clientAssertionLifetime = time.Minute
+)
+
+// ErrTokenRejected is returned when the pre-issued Management API token expired or
+// was rejected, since it can't be renewed.
+var ErrTokenRejected = errors.New("baton-auth0: the Management API token expired or was rejected")
+
+type clientOptions struct {
+	requestsPerSecond     float64
+	maxConcurrentRequests int
+	tokenCacheDir         string
+	userQuery             string
+	privateKey            *privateKeyJWT
+	managementAPIToken    string
+	mtls                  *mtlsAuthentication
+}
+
+type ClientOpt func(options *clientOptions)
+
+// WithRequestsPerSecond caps how many requests per second the client sends to the
+// Management API.
+func WithRequestsPerSecond(requestsPerSecond float64) ClientOpt {
+	return func(options *clientOptions) {
+		options.requestsPerSecond = requestsPerSecond
+	}
+}
+
+// WithMaxConcurrentRequests caps how many requests the client has in flight at a
+// time, across all goroutines using it.
+func WithMaxConcurrentRequests(maxConcurrentRequests int) ClientOpt {
+	return func(options *clientOptions) {
+		options.maxConcurrentRequests = maxConcurrentRequests
+	}
+}
+
+// WithTokenCache caches access tokens in dir, so that they are reused across runs
+// until they expire.
+func WithTokenCache(dir string) ClientOpt {
+	return func(options *clientOptions) {
+		options.tokenCacheDir = dir
+	}
+}
+
+// WithUserQuery restricts the users returned by GetUsers to those also matching a
+// user search query.
+func WithUserQuery(query string) ClientOpt {
+	return func(options *clientOptions) {
+		options.userQuery = query
+	}
+}
+
+type privateKeyJWT struct {
+	pem    []byte
+	signer jose.Signer
+}
+
+// WithPrivateKeyJWT authenticates token requests with client assertions signed
+// with a PEM encoded RSA private key, using RS256 or PS256. keyId is the ID of
+// the credential registered for the application in Auth0, sent as the kid header.
+func WithPrivateKeyJWT(privateKeyPEM string, keyId string, algorithm string) (ClientOpt, error) {
+	key, err := parseRSAPrivateKey([]byte(privateKeyPEM))
+	if err != nil {
+		return nil, err
+	}
+
+	signatureAlgorithm := jose.SignatureAlgorithm(strings.ToUpper(algorithm))
+	switch signatureAlgorithm {
+	case jose.RS256, jose.PS256:
+	case "":
+		signatureAlgorithm = jose.RS256
+	default:
+		return nil, fmt.Errorf("baton-auth0: unsupported client assertion algorithm %q", algorithm)
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{
+			Algorithm: signatureAlgorithm,
+			Key:       jose.JSONWebKey{Key: key, KeyID: keyId},
+		},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(options *clientOptions) {
+		options.privateKey = &privateKeyJWT{pem: []byte(privateKeyPEM), signer: signer}
+	}, nil
+}
+
+// WithManagementAPIToken uses a pre-issued Management API token instead of
+// requesting one. The token can't be renewed, so requests fail once it expires.
+func WithManagementAPIToken(token string) ClientOpt {
+	return func(options *clientOptions) {
+		options.managementAPIToken = token
+	}
+}
+
+type mtlsAuthentication struct {
+	keyPEM      []byte
+	certificate tls.Certificate
+	tokenUrl    string
+}
+
+// WithMTLS authenticates token requests with a TLS client certificate. tokenUrl is
+// the token endpoint of the tenant's mTLS custom domain, and defaults to the token
+// endpoint of the base URL.
+func WithMTLS(certificatePEM string, keyPEM string, tokenUrl string) (ClientOpt, error) {
+	certificate, err := tls.X509KeyPair([]byte(certificatePEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("baton-auth0: invalid mTLS client certificate: %w", err)
+	}
+
+	return func(options *clientOptions) {
+		options.mtls = &mtlsAuthentication{
+			keyPEM:      []byte(keyPEM),
+			certificate: certificate,
+			tokenUrl:    tokenUrl,
+		}
+	}, nil
+}
+
+// newTokenSource returns the token source for the configured authentication
+// method: a pre-issued token, a private_key_jwt client assertion, a TLS client
+// certificate, or by default the client secret.
+func (c *Client) newTokenSource(clientId string, clientSecret string, options clientOptions) (*tokenSource, error) {
+	if options.managementAPIToken != "" {
+		return newStaticTokenSource(options.managementAPIToken), nil
+	}
+
+	tokenUrl := c.BaseUrl.JoinPath(apiPathAuth)
+	var fetch func(ctx context.Context) (accessToken, error)
+	var secret []byte
+	switch {
+	case options.privateKey != nil:
+		secret = options.privateKey.pem
+		fetch = func(ctx context.Context) (accessToken, error) {
+			assertion, err := c.clientAssertion(clientId, options.privateKey.signer)
+			if err != nil {
+				return accessToken{}, err
+			}
+			return c.authorize(ctx, tokenUrl, url.Values{
+				"client_id":             {clientId},
+				"client_assertion_type": {clientAssertionType},
+				"client_assertion":      {assertion},
+			})
+		}
+
+	case options.mtls != nil:
+		if options.mtls.tokenUrl != "" {
+			var err error
+			tokenUrl, err = url.Parse(options.mtls.tokenUrl)
+			if err != nil {
+				return nil, err
+			}
+		}
+		secret = options.mtls.keyPEM
+		fetch = func(ctx context.Context) (accessToken, error) {
+			return c.authorize(ctx, tokenUrl, url.Values{
+				"client_id": {clientId},
+			})
+		}
+
+	default:
+		secret = []byte(clientSecret)
+		fetch = func(ctx context.Context) (accessToken, error) {
+			return c.authorize(ctx, tokenUrl, url.Values{
+				"client_id":     {clientId},
+				"client_secret": {clientSecret},
+			})
+		}
+	}
+
+	source := newTokenSource(fetch)
+	if options.tokenCacheDir != "" {
+		var err error
+		source.cache, err = newTokenCache(options.tokenCacheDir, c.BaseUrl.Host, clientId, c.audience(), secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return source, nil
+}
+
+// clientAssertion returns a signed JWT authenticating the client to the tenant's
+// token endpoint.
+func (c *Client) clientAssertion(clientId string, signer jose.Signer) (string, error) {
+	jti := make([]byte, 16)
+	_, err := rand.Read(jti)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return jwt.Signed(signer).Claims(jwt.Claims{
+		Issuer:   clientId,
+		Subject:  clientId,
+		Audience: jwt.Audience{c.BaseUrl.JoinPath("/").String()},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(clientAssertionLifetime)),
+		ID:       hex.EncodeToString(jti),
+	}).Serialize()
+}
+
+func parseRSAPrivateKey(content []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("baton-auth0: the private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("baton-auth0: invalid private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("baton-auth0: the private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// newStaticTokenSource returns a token source that always uses the pre-issued
+// token. It expires along with the token, which can't be renewed.
+func newStaticTokenSource(token string) *tokenSource {
+	expiresAt := tokenExpiry(token)
+	source := newTokenSource(func(context.Context) (accessToken, error) {
+		return accessToken{}, ErrTokenRejected
+	})
+	if expiresAt.IsZero() || time.Now().Before(expiresAt) {
+		source.current = accessToken{value: token}
+	}
+	return source
+}
+
+// tokenExpiry returns the expiry of a JWT access token, or the zero time if it has
+// none. The token is not verified, since the API does that.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Expiry int64 `json:"exp"`
+	}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || claims.Expiry == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Expiry, 0)
+}
@@ -2,10 +2,14 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
@@ -13,9 +17,12 @@ import (
 )
 
 type Client struct {
-	wrapper     *uhttp.BaseHttpClient
-	BearerToken string
-	BaseUrl     *url.URL
+	wrapper *uhttp.BaseHttpClient
+	token   *tokenSource
+	limiter *rateLimiter
+	BaseUrl *url.URL
+	// userQuery is ANDed into the query of every user search.
+	userQuery string
 }
 
 type ReqOpt func(reqURL *url.URL)
@@ -33,17 +40,34 @@ func New(
 	baseUrl string,
 	clientId string,
 	clientSecret string,
+	opts ...ClientOpt,
 ) (*Client, error) {
-	httpClient, err := uhttp.NewClient(
-		ctx,
+	var options clientOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	httpOptions := []uhttp.Option{
 		uhttp.WithLogger(
 			true,
 			ctxzap.Extract(ctx),
 		),
-	)
+	}
+	if options.mtls != nil {
+		httpOptions = append(httpOptions, uhttp.WithTLSClientConfig(&tls.Config{
+			Certificates: []tls.Certificate{options.mtls.certificate},
+			MinVersion:   tls.VersionTLS12,
+		}))
+	}
+	httpClient, err := uhttp.NewClient(ctx, httpOptions...)
 	if err != nil {
 		return nil, err
 	}
+	limiter := newRateLimiter(options.requestsPerSecond)
+	httpClient.Transport = newRateLimitObserver(
+		newConcurrencyLimiter(httpClient.Transport, options.maxConcurrentRequests),
+		limiter,
+	)
 
 	wrapper, err := uhttp.NewBaseHttpClientWithContext(ctx, httpClient)
 	if err != nil {
@@ -56,11 +80,18 @@ func New(
 	}
 
 	client := Client{
-		wrapper: wrapper,
-		BaseUrl: baseUrl0,
+		wrapper:   wrapper,
+		limiter:   limiter,
+		BaseUrl:   baseUrl0,
+		userQuery: options.userQuery,
+	}
+	client.token, err = client.newTokenSource(clientId, clientSecret, options)
+	if err != nil {
+		return nil, err
 	}
 
-	err = client.Authorize(ctx, clientId, clientSecret)
+	// Fetch the first token right away so that invalid credentials fail early.
+	_, err = client.token.Token(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -68,39 +99,46 @@ func New(
 	return &client, nil
 }
 
-func (c *Client) Authorize(
+// audience is the identifier of the Management API that tokens are requested for.
+func (c *Client) audience() string {
+	return c.BaseUrl.JoinPath(apiPathBase).String()
+}
+
+// authorize fetches a Management API access token with the client credentials
+// grant from the token endpoint. form holds the client authentication parameters.
+func (c *Client) authorize(
 	ctx context.Context,
-	clientId string,
-	clientSecret string,
-) error {
+	tokenUrl *url.URL,
+	form url.Values,
+) (accessToken, error) {
 	var target AuthResponse
-	form := &url.Values{}
-	form.Set("audience", c.BaseUrl.JoinPath(apiPathBase).String())
-	form.Set("client_id", clientId)
-	form.Set("client_secret", clientSecret)
+	form.Set("audience", c.audience())
 	form.Set("grant_type", "client_credentials")
 
 	options := []uhttp.RequestOption{
 		uhttp.WithFormBody(form.Encode()),
 	}
 
-	url := c.BaseUrl.JoinPath(apiPathAuth)
-	request, err := c.wrapper.NewRequest(ctx, http.MethodPost, url, options...)
+	request, err := c.wrapper.NewRequest(ctx, http.MethodPost, tokenUrl, options...)
 	if err != nil {
-		return err
+		return accessToken{}, err
 	}
 
+	requestedAt := time.Now()
 	response, err := c.wrapper.Do(
 		request,
 		uhttp.WithJSONResponse(&target),
 	)
 	if err != nil {
-		return fmt.Errorf("error authorizing: %w", err)
+		return accessToken{}, fmt.Errorf("error authorizing: %w", err)
 	}
 
 	defer response.Body.Close()
-	c.BearerToken = target.AccessToken
-	return nil
+	token := accessToken{value: target.AccessToken}
+	if target.ExpiresIn > 0 {
+		token.expiresAt = requestedAt.Add(time.Duration(target.ExpiresIn) * time.Second)
+	}
+	return token, nil
 }
 
 func (c *Client) List(
@@ -132,8 +170,8 @@ func (c *Client) GetUsers(
 	ctx context.Context,
 	limit int,
 	page int,
-	since string,
-	until string,
+	query string,
+	sort string,
 ) (
 	[]User,
 	int,
@@ -148,8 +186,8 @@ func (c *Client) GetUsers(
 		WithQueryParam("include_totals", "true"),
 		WithQueryParam("page", strconv.Itoa(page)),
 		WithQueryParam("per_page", strconv.Itoa(limit)),
-		WithQueryParam("sort", "created_at:1"),
-		WithQueryParam("q", fmt.Sprintf("created_at:[%s TO %s]", since, until)),
+		WithQueryParam("sort", sort),
+		WithQueryParam("q", c.usersQuery(query)),
 	)
 	if err != nil {
 		return nil, 0, rateLimitData, err
@@ -158,6 +196,202 @@ func (c *Client) GetUsers(
 	return target.Users, target.Total, rateLimitData, nil
 }
 
+// QuoteSearchValue quotes a value for a user search query.
+func QuoteSearchValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// usersQuery ANDs the configured user query into a user search query.
+func (c *Client) usersQuery(query string) string {
+	switch {
+	case c.userQuery == "":
+		return query
+	case query == "":
+		return c.userQuery
+	}
+	return fmt.Sprintf("(%s) AND (%s)", query, c.userQuery)
+}
+
+func (c *Client) GetUser(
+	ctx context.Context,
+	userId string,
+) (
+	*User,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target User
+	response, rateLimitData, err := c.get(
+		ctx,
+		fmt.Sprintf(apiPathUser, userId),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+func (c *Client) GetUsersByEmail(
+	ctx context.Context,
+	email string,
+) (
+	[]User,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target []User
+	response, rateLimitData, err := c.get(
+		ctx,
+		apiPathUsersByEmail,
+		&target,
+		[]ReqOpt{WithQueryParam("email", email)},
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return target, rateLimitData, nil
+}
+
+func (c *Client) CreateUser(
+	ctx context.Context,
+	user CreateUserRequest,
+) (
+	*User,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target User
+	response, rateLimitData, err := c.post(
+		ctx,
+		apiPathGetUsers,
+		user,
+		&target,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+// CreatePasswordChangeTicket returns a single-use URL that lets the user choose
+// their own password. Following it also marks the user's email as verified.
+func (c *Client) CreatePasswordChangeTicket(
+	ctx context.Context,
+	userId string,
+) (
+	string,
+	*v2.RateLimitDescription,
+	error,
+) {
+	body := map[string]interface{}{
+		"user_id":                userId,
+		"mark_email_as_verified": true,
+	}
+
+	var target PasswordChangeTicket
+	response, rateLimitData, err := c.post(
+		ctx,
+		apiPathPasswordChangeTicket,
+		body,
+		&target,
+	)
+	if err != nil {
+		return "", rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return target.Ticket, rateLimitData, nil
+}
+
+func (c *Client) DeleteUser(
+	ctx context.Context,
+	userId string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.deleteNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathUser, userId),
+		nil,
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+// SetUserBlocked blocks or unblocks a user. Blocked users can't log in.
+func (c *Client) SetUserBlocked(
+	ctx context.Context,
+	userId string,
+	blocked bool,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	body := map[string]interface{}{
+		"blocked": blocked,
+	}
+
+	response, rateLimitData, err := c.patchNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathUser, userId),
+		body,
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+// UpdateUserPassword sets the password of a user in the given database connection.
+func (c *Client) UpdateUserPassword(
+	ctx context.Context,
+	userId string,
+	connection string,
+	password string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	body := map[string]interface{}{
+		"password":   password,
+		"connection": connection,
+	}
+
+	response, rateLimitData, err := c.patchNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathUser, userId),
+		body,
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
 func (c *Client) GetRoles(
 	ctx context.Context,
 	limit int,
@@ -184,6 +418,54 @@ func (c *Client) GetRoles(
 	return target.Roles, target.Total, rateLimitData, nil
 }
 
+func (c *Client) GetRole(
+	ctx context.Context,
+	roleId string,
+) (
+	*Role,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target Role
+	response, rateLimitData, err := c.get(
+		ctx,
+		fmt.Sprintf(apiPathRole, roleId),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+func (c *Client) GetOrganization(
+	ctx context.Context,
+	organizationId string,
+) (
+	*Organization,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target Organization
+	response, rateLimitData, err := c.get(
+		ctx,
+		fmt.Sprintf(apiPathOrganization, organizationId),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
 func (c *Client) GetOrganizations(
 	ctx context.Context,
 	limit int,
@@ -210,13 +492,15 @@ func (c *Client) GetOrganizations(
 	return target.Organizations, target.Total, rateLimitData, nil
 }
 
+// GetOrganizationMembers fetches one page of an organization's members, including
+// the roles each member has been assigned within the organization.
 func (c *Client) GetOrganizationMembers(
 	ctx context.Context,
 	organizationId string,
 	limit int,
 	page int,
 ) (
-	[]User,
+	[]OrganizationMember,
 	int,
 	*v2.RateLimitDescription,
 	error,
@@ -229,6 +513,7 @@ func (c *Client) GetOrganizationMembers(
 		WithQueryParam("include_totals", "true"),
 		WithQueryParam("page", strconv.Itoa(page)),
 		WithQueryParam("per_page", strconv.Itoa(limit)),
+		WithQueryParam("fields", "user_id,email,name,roles"),
 	)
 	if err != nil {
 		return nil, 0, rateLimitData, err
@@ -367,46 +652,86 @@ func (c *Client) RemoveUserFromOrganization(
 	return rateLimitData, nil
 }
 
-func (c *Client) GetResourceServers(
+// GetOrganizationInvitations fetches one page of an organization's pending
+// invitations. Auth0 does not return a total for invitations, so callers should
+// stop once a page comes back with fewer than limit results.
+func (c *Client) GetOrganizationInvitations(
 	ctx context.Context,
+	organizationId string,
 	limit int,
 	page int,
 ) (
-	[]*ResourceServer,
-	int,
+	[]OrganizationInvitation,
 	*v2.RateLimitDescription,
 	error,
 ) {
-	var target ResourceServerResponse
+	var target []OrganizationInvitation
 	rateLimitData, err := c.List(
 		ctx,
-		apiPathGetResourceServers,
+		fmt.Sprintf(apiPathOrganizationInvitations, organizationId),
 		&target,
-		WithQueryParam("include_totals", "true"),
 		WithQueryParam("page", strconv.Itoa(page)),
 		WithQueryParam("per_page", strconv.Itoa(limit)),
 	)
 	if err != nil {
-		return nil, 0, rateLimitData, err
+		return nil, rateLimitData, err
 	}
 
-	return target.ResourceServers, target.Total, rateLimitData, nil
+	return target, rateLimitData, nil
 }
 
-func (c *Client) GetResourceServer(
+// CreateOrganizationInvitation invites an email address to an organization and has
+// Auth0 send the invitation email. Roles are assigned within the organization once
+// the invitation is accepted.
+func (c *Client) CreateOrganizationInvitation(
 	ctx context.Context,
-	id string,
+	organizationId string,
+	inviterName string,
+	inviteeEmail string,
+	clientId string,
+	roles []string,
 ) (
-	*ResourceServer,
 	*v2.RateLimitDescription,
 	error,
 ) {
-	var target ResourceServer
+	body := map[string]interface{}{
+		"inviter":               Inviter{Name: inviterName},
+		"invitee":               Invitee{Email: inviteeEmail},
+		"client_id":             clientId,
+		"send_invitation_email": true,
+	}
+	if len(roles) > 0 {
+		body["roles"] = roles
+	}
+
+	response, rateLimitData, err := c.postNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathOrganizationInvitations, organizationId),
+		body,
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+// GetTenantSettings returns the settings of the tenant.
+func (c *Client) GetTenantSettings(
+	ctx context.Context,
+) (
+	*TenantSettings,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target TenantSettings
 	response, rateLimitData, err := c.get(
 		ctx,
-		fmt.Sprintf(apiPathResourceServers, id),
+		apiPathTenantSettings,
 		&target,
-		nil,
+		[]ReqOpt{WithQueryParam("fields", "friendly_name")},
 	)
 	if err != nil {
 		return nil, rateLimitData, err
@@ -417,26 +742,684 @@ func (c *Client) GetResourceServer(
 	return &target, rateLimitData, nil
 }
 
-func (c *Client) GetRolePermissions(
+func (c *Client) GetOrganizationConnections(
 	ctx context.Context,
-	id string,
+	organizationId string,
+	limit int,
+	page int,
 ) (
-	[]*RolePermission,
+	[]OrganizationConnection,
+	int,
 	*v2.RateLimitDescription,
 	error,
 ) {
-	var target []*RolePermission
-	response, rateLimitData, err := c.get(
+	var target OrganizationConnectionsResponse
+	rateLimitData, err := c.List(
 		ctx,
-		fmt.Sprintf(apiPathRolePermissions, id),
+		fmt.Sprintf(apiPathOrganizationConnections, organizationId),
 		&target,
-		nil,
+		WithQueryParam("include_totals", "true"),
+		WithQueryParam("page", strconv.Itoa(page)),
+		WithQueryParam("per_page", strconv.Itoa(limit)),
 	)
 	if err != nil {
-		return nil, rateLimitData, err
+		return nil, 0, rateLimitData, err
 	}
 
-	defer response.Body.Close()
+	return target.EnabledConnections, target.Total, rateLimitData, nil
+}
 
-	return target, rateLimitData, nil
+// AddConnectionToOrganization enables a connection on an organization without
+// turning on membership assignment on login.
+func (c *Client) AddConnectionToOrganization(
+	ctx context.Context,
+	organizationId string,
+	connectionId string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.postNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathOrganizationConnections, organizationId),
+		map[string]interface{}{
+			"connection_id":              connectionId,
+			"assign_membership_on_login": false,
+		},
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func (c *Client) RemoveConnectionFromOrganization(
+	ctx context.Context,
+	organizationId string,
+	connectionId string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.deleteNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathOrganizationConnection, organizationId, connectionId),
+		nil,
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func (c *Client) AddRoleToOrganizationMember(
+	ctx context.Context,
+	organizationId string,
+	userId string,
+	roleId string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.postNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathOrganizationMemberRoles, organizationId, userId),
+		map[string]interface{}{
+			"roles": []string{roleId},
+		},
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func (c *Client) RemoveRoleFromOrganizationMember(
+	ctx context.Context,
+	organizationId string,
+	userId string,
+	roleId string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.deleteNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathOrganizationMemberRoles, organizationId, userId),
+		map[string]interface{}{
+			"roles": []string{roleId},
+		},
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+// GetLogs returns up to take log entries. With a from log ID the entries logged
+// after it are returned, oldest first. Without one the oldest entries logged since
+// the given date are returned. The logs are polled for new entries, so they are never
+// served from the cache.
+func (c *Client) GetLogs(
+	ctx context.Context,
+	from string,
+	since time.Time,
+	take int,
+) (
+	[]Log,
+	*v2.RateLimitDescription,
+	error,
+) {
+	opts := []ReqOpt{
+		WithQueryParam("from", from),
+		WithQueryParam("take", strconv.Itoa(take)),
+	}
+	if from == "" {
+		opts = []ReqOpt{
+			WithQueryParam("q", fmt.Sprintf("date:[%s TO *]", since.UTC().Format(time.RFC3339Nano))),
+			WithQueryParam("sort", "date:1"),
+			WithQueryParam("page", "0"),
+			WithQueryParam("per_page", strconv.Itoa(take)),
+		}
+	}
+
+	var target []Log
+	response, rateLimitData, err := c.getNoCache(
+		ctx,
+		apiPathGetLogs,
+		&target,
+		opts,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return target, rateLimitData, nil
+}
+
+// GetLog returns a single log entry. Entries older than the tenant's log retention
+// period are no longer found.
+func (c *Client) GetLog(
+	ctx context.Context,
+	logId string,
+) (
+	*Log,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target Log
+	response, rateLimitData, err := c.getNoCache(
+		ctx,
+		fmt.Sprintf(apiPathLog, logId),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+// GetLatestLog returns the most recent log entry, or nil if the tenant has none.
+func (c *Client) GetLatestLog(
+	ctx context.Context,
+) (
+	*Log,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target []Log
+	response, rateLimitData, err := c.getNoCache(
+		ctx,
+		apiPathGetLogs,
+		&target,
+		[]ReqOpt{
+			WithQueryParam("sort", "date:-1"),
+			WithQueryParam("page", "0"),
+			WithQueryParam("per_page", "1"),
+		},
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	if len(target) == 0 {
+		return nil, rateLimitData, nil
+	}
+
+	return &target[0], rateLimitData, nil
+}
+
+func (c *Client) GetResourceServers(
+	ctx context.Context,
+	limit int,
+	page int,
+) (
+	[]*ResourceServer,
+	int,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target ResourceServerResponse
+	rateLimitData, err := c.List(
+		ctx,
+		apiPathGetResourceServers,
+		&target,
+		WithQueryParam("include_totals", "true"),
+		WithQueryParam("page", strconv.Itoa(page)),
+		WithQueryParam("per_page", strconv.Itoa(limit)),
+	)
+	if err != nil {
+		return nil, 0, rateLimitData, err
+	}
+
+	return target.ResourceServers, target.Total, rateLimitData, nil
+}
+
+func (c *Client) GetClients(
+	ctx context.Context,
+	limit int,
+	page int,
+) (
+	[]Application,
+	int,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target ClientsResponse
+	rateLimitData, err := c.List(
+		ctx,
+		apiPathGetClients,
+		&target,
+		WithQueryParam("include_totals", "true"),
+		WithQueryParam("page", strconv.Itoa(page)),
+		WithQueryParam("per_page", strconv.Itoa(limit)),
+	)
+	if err != nil {
+		return nil, 0, rateLimitData, err
+	}
+
+	return target.Clients, target.Total, rateLimitData, nil
+}
+
+func (c *Client) GetConnections(
+	ctx context.Context,
+	limit int,
+	page int,
+) (
+	[]Connection,
+	int,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target ConnectionsResponse
+	rateLimitData, err := c.List(
+		ctx,
+		apiPathGetConnections,
+		&target,
+		WithQueryParam("include_totals", "true"),
+		WithQueryParam("page", strconv.Itoa(page)),
+		WithQueryParam("per_page", strconv.Itoa(limit)),
+	)
+	if err != nil {
+		return nil, 0, rateLimitData, err
+	}
+
+	return target.Connections, target.Total, rateLimitData, nil
+}
+
+// GetConnectionByName returns the connection with the given name. Names are unique
+// within a tenant.
+func (c *Client) GetConnectionByName(
+	ctx context.Context,
+	name string,
+) (
+	*Connection,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target []Connection
+	rateLimitData, err := c.List(
+		ctx,
+		apiPathGetConnections,
+		&target,
+		WithQueryParam("name", name),
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	for _, connection := range target {
+		if connection.Name == name {
+			return &connection, rateLimitData, nil
+		}
+	}
+
+	return nil, rateLimitData, fmt.Errorf("connection %s not found", name)
+}
+
+func (c *Client) GetConnection(
+	ctx context.Context,
+	connectionId string,
+) (
+	*Connection,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target Connection
+	// Connections are read before changing them, so they must not be served from
+	// the cache.
+	response, rateLimitData, err := c.getNoCache(
+		ctx,
+		fmt.Sprintf(apiPathConnection, connectionId),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+// SetConnectionClientEnabled enables or disables an application on a connection.
+// Auth0 updates each listed application on its own, so concurrent changes to other
+// applications are kept. Returns false if the application was already in the
+// requested state.
+func (c *Client) SetConnectionClientEnabled(
+	ctx context.Context,
+	connectionId string,
+	clientId string,
+	enabled bool,
+) (
+	bool,
+	*v2.RateLimitDescription,
+	error,
+) {
+	connection, rateLimitData, err := c.GetConnection(ctx, connectionId)
+	if err != nil {
+		return false, rateLimitData, err
+	}
+
+	if slices.Contains(connection.EnabledClients, clientId) == enabled {
+		return false, rateLimitData, nil
+	}
+
+	response, rateLimitData, err := c.patchNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathConnectionClients, connectionId),
+		[]ConnectionClientStatus{{ClientId: clientId, Status: enabled}},
+	)
+	if err != nil {
+		return false, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return true, rateLimitData, nil
+}
+
+// GetClientGrants fetches one page of client grants. Empty audience or clientId
+// values are not used as filters.
+func (c *Client) GetClientGrants(
+	ctx context.Context,
+	audience string,
+	clientId string,
+	limit int,
+	page int,
+) (
+	[]ClientGrant,
+	int,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target ClientGrantsResponse
+	opts := []ReqOpt{
+		WithQueryParam("include_totals", "true"),
+		WithQueryParam("page", strconv.Itoa(page)),
+		WithQueryParam("per_page", strconv.Itoa(limit)),
+	}
+	if audience != "" {
+		opts = append(opts, WithQueryParam("audience", audience))
+	}
+	if clientId != "" {
+		opts = append(opts, WithQueryParam("client_id", clientId))
+	}
+	rateLimitData, err := c.List(
+		ctx,
+		apiPathGetClientGrants,
+		&target,
+		opts...,
+	)
+	if err != nil {
+		return nil, 0, rateLimitData, err
+	}
+
+	return target.ClientGrants, target.Total, rateLimitData, nil
+}
+
+// GetClientGrant returns the client grant between an application and a resource
+// server, or nil if there is none. Auth0 allows at most one per pair. Client grants
+// are read before changing their scopes, so they must not be served from the cache.
+func (c *Client) GetClientGrant(
+	ctx context.Context,
+	audience string,
+	clientId string,
+) (
+	*ClientGrant,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target ClientGrantsResponse
+	response, rateLimitData, err := c.getNoCache(
+		ctx,
+		apiPathGetClientGrants,
+		&target,
+		[]ReqOpt{
+			WithQueryParam("include_totals", "true"),
+			WithQueryParam("audience", audience),
+			WithQueryParam("client_id", clientId),
+		},
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	for _, clientGrant := range target.ClientGrants {
+		if clientGrant.ClientId == clientId && clientGrant.Audience == audience {
+			return &clientGrant, rateLimitData, nil
+		}
+	}
+
+	return nil, rateLimitData, nil
+}
+
+func (c *Client) CreateClientGrant(
+	ctx context.Context,
+	clientId string,
+	audience string,
+	scope []string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.postNoJSONResponse(
+		ctx,
+		apiPathGetClientGrants,
+		map[string]interface{}{
+			"client_id": clientId,
+			"audience":  audience,
+			"scope":     scope,
+		},
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+// UpdateClientGrantScope replaces the full list of scopes on a client grant.
+func (c *Client) UpdateClientGrantScope(
+	ctx context.Context,
+	clientGrantId string,
+	scope []string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.patchNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathClientGrant, clientGrantId),
+		map[string]interface{}{
+			"scope": scope,
+		},
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func (c *Client) DeleteClientGrant(
+	ctx context.Context,
+	clientGrantId string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.deleteNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathClientGrant, clientGrantId),
+		nil,
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func (c *Client) GetResourceServer(
+	ctx context.Context,
+	id string,
+) (
+	*ResourceServer,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target ResourceServer
+	response, rateLimitData, err := c.get(
+		ctx,
+		fmt.Sprintf(apiPathResourceServers, id),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+func (c *Client) GetRolePermissions(
+	ctx context.Context,
+	id string,
+) (
+	[]*RolePermission,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target []*RolePermission
+	response, rateLimitData, err := c.get(
+		ctx,
+		fmt.Sprintf(apiPathRolePermissions, id),
+		&target,
+		nil,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return target, rateLimitData, nil
+}
+
+// GetUserPermissions fetches one page of the permissions assigned directly to a user,
+// not including the ones the user gets through roles.
+func (c *Client) GetUserPermissions(
+	ctx context.Context,
+	userId string,
+	limit int,
+	page int,
+) (
+	[]RolePermission,
+	int,
+	*v2.RateLimitDescription,
+	error,
+) {
+	var target UserPermissionsResponse
+	rateLimitData, err := c.List(
+		ctx,
+		fmt.Sprintf(apiPathUserPermissions, userId),
+		&target,
+		WithQueryParam("include_totals", "true"),
+		WithQueryParam("page", strconv.Itoa(page)),
+		WithQueryParam("per_page", strconv.Itoa(limit)),
+	)
+	if err != nil {
+		return nil, 0, rateLimitData, err
+	}
+
+	return target.Permissions, target.Total, rateLimitData, nil
+}
+
+func (c *Client) AddPermissionToUser(
+	ctx context.Context,
+	userId string,
+	resourceServerIdentifier string,
+	permissionName string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.postNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathUserPermissions, userId),
+		userPermissionsBody(resourceServerIdentifier, permissionName),
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func (c *Client) RemovePermissionFromUser(
+	ctx context.Context,
+	userId string,
+	resourceServerIdentifier string,
+	permissionName string,
+) (
+	*v2.RateLimitDescription,
+	error,
+) {
+	response, rateLimitData, err := c.deleteNoJSONResponse(
+		ctx,
+		fmt.Sprintf(apiPathUserPermissions, userId),
+		userPermissionsBody(resourceServerIdentifier, permissionName),
+	)
+	if err != nil {
+		return rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return rateLimitData, nil
+}
+
+func userPermissionsBody(resourceServerIdentifier string, permissionName string) map[string]interface{} {
+	return map[string]interface{}{
+		"permissions": []map[string]string{
+			{
+				"resource_server_identifier": resourceServerIdentifier,
+				"permission_name":            permissionName,
+			},
+		},
+	}
 }
@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+// authServer records the token requests it gets and the token API requests are
+// made with.
+type authServer struct {
+	mu            sync.Mutex
+	tokenRequests map[string][]url.Values
+	authorization []string
+}
+
+func (s *authServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasSuffix(r.URL.Path, "/oauth/token") {
+		_ = r.ParseForm()
+		if s.tokenRequests == nil {
+			s.tokenRequests = make(map[string][]url.Values)
+		}
+		s.tokenRequests[r.URL.Path] = append(s.tokenRequests[r.URL.Path], r.PostForm)
+		_ = json.NewEncoder(w).Encode(AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+		return
+	}
+
+	s.authorization = append(s.authorization, r.Header.Get("Authorization"))
+	_ = json.NewEncoder(w).Encode(User{UserId: "auth0|1"})
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func pemEncode(blockType string, content []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: content}))
+}
+
+func TestParseRSAPrivateKey(t *testing.T) {
+	key := newRSAKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		pem  string
+		err  string
+	}{
+		{name: "PKCS #1", pem: pemEncode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))},
+		{name: "PKCS #8", pem: pemEncode("PRIVATE KEY", pkcs8)},
+		{name: "not PEM", pem: "not a key", err: "the private key is not PEM encoded"},
+		{name: "invalid key", pem: pemEncode("PRIVATE KEY", []byte("garbage")), err: "invalid private key"},
+		{name: "EC key", pem: pemEncode("PRIVATE KEY", ecPKCS8), err: "the private key is not an RSA key"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := parseRSAPrivateKey([]byte(test.pem))
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, key.Equal(parsed))
+		})
+	}
+}
+
+func TestPrivateKeyJWT(t *testing.T) {
+	ctx := context.Background()
+
+	key := newRSAKey(t)
+	keyPEM := pemEncode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+
+	tests := []struct {
+		name      string
+		algorithm string
+		expected  jose.SignatureAlgorithm
+	}{
+		{name: "default", algorithm: "", expected: jose.RS256},
+		{name: "RS256", algorithm: "RS256", expected: jose.RS256},
+		{name: "PS256", algorithm: "ps256", expected: jose.PS256},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant := &authServer{}
+			server := httptest.NewServer(tenant)
+			defer server.Close()
+
+			opt, err := WithPrivateKeyJWT(keyPEM, "key_1", test.algorithm)
+			require.NoError(t, err)
+			_, err = New(ctx, server.URL, "client_1", "", opt)
+			require.NoError(t, err)
+
+			require.Len(t, tenant.tokenRequests["/oauth/token"], 1)
+			form := tenant.tokenRequests["/oauth/token"][0]
+			require.Equal(t, "client_1", form.Get("client_id"))
+			require.Equal(t, clientAssertionType, form.Get("client_assertion_type"))
+			require.Empty(t, form.Get("client_secret"))
+			require.Equal(t, server.URL+"/api/v2/", form.Get("audience"))
+			require.Equal(t, "client_credentials", form.Get("grant_type"))
+
+			assertion, err := jwt.ParseSigned(form.Get("client_assertion"), []jose.SignatureAlgorithm{jose.RS256, jose.PS256})
+			require.NoError(t, err)
+			require.Len(t, assertion.Headers, 1)
+			require.Equal(t, "key_1", assertion.Headers[0].KeyID)
+			require.EqualValues(t, test.expected, assertion.Headers[0].Algorithm)
+
+			var claims jwt.Claims
+			err = assertion.Claims(&key.PublicKey, &claims)
+			require.NoError(t, err)
+			// The audience is the tenant's domain with a trailing slash.
+			err = claims.Validate(jwt.Expected{
+				Issuer:      "client_1",
+				Subject:     "client_1",
+				AnyAudience: jwt.Audience{server.URL + "/"},
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, claims.ID)
+			require.Equal(t, clientAssertionLifetime, claims.Expiry.Time().Sub(claims.IssuedAt.Time()))
+		})
+	}
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := WithPrivateKeyJWT(keyPEM, "key_1", "ES256")
+		require.ErrorContains(t, err, `unsupported client assertion algorithm "ES256"`)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := WithPrivateKeyJWT("not a key", "key_1", "RS256")
+		require.ErrorContains(t, err, "the private key is not PEM encoded")
+	})
+}
+
+// newClientCertificate returns a self-signed client certificate and its key, PEM
+// encoded.
+func newClientCertificate(t *testing.T) (string, string) {
+	key := newRSAKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client_1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pemEncode("CERTIFICATE", certificate), pemEncode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func TestMTLS(t *testing.T) {
+	ctx := context.Background()
+
+	certificatePEM, keyPEM := newClientCertificate(t)
+
+	t.Run("token URL", func(t *testing.T) {
+		tenant := &authServer{}
+		server := httptest.NewServer(tenant)
+		defer server.Close()
+
+		opt, err := WithMTLS(certificatePEM, keyPEM, server.URL+"/mtls/oauth/token")
+		require.NoError(t, err)
+		c, err := New(ctx, server.URL, "client_1", "", opt)
+		require.NoError(t, err)
+
+		// Tokens are requested from the mTLS custom domain, for the Management API
+		// of the tenant's domain, without a client secret.
+		require.Empty(t, tenant.tokenRequests["/oauth/token"])
+		require.Len(t, tenant.tokenRequests["/mtls/oauth/token"], 1)
+		form := tenant.tokenRequests["/mtls/oauth/token"][0]
+		require.Equal(t, url.Values{
+			"client_id":  {"client_1"},
+			"audience":   {server.URL + "/api/v2/"},
+			"grant_type": {"client_credentials"},
+		}, form)
+
+		_, _, err = c.GetUser(ctx, "auth0|1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"Bearer mock-token"}, tenant.authorization)
+	})
+
+	t.Run("default token URL", func(t *testing.T) {
+		tenant := &authServer{}
+		server := httptest.NewServer(tenant)
+		defer server.Close()
+
+		opt, err := WithMTLS(certificatePEM, keyPEM, "")
+		require.NoError(t, err)
+		_, err = New(ctx, server.URL, "client_1", "", opt)
+		require.NoError(t, err)
+		require.Len(t, tenant.tokenRequests["/oauth/token"], 1)
+	})
+
+	t.Run("mismatched key", func(t *testing.T) {
+		_, otherKeyPEM := newClientCertificate(t)
+		_, err := WithMTLS(certificatePEM, otherKeyPEM, "")
+		require.ErrorContains(t, err, "invalid mTLS client certificate")
+	})
+}
+
+// unsignedJWT returns a JWT with the given claims. The signature isn't checked by
+// the client, so it is left out.
+func unsignedJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	require.True(t, expiresAt.Equal(tokenExpiry(unsignedJWT(fmt.Sprintf(`{"exp":%d}`, expiresAt.Unix())))))
+	require.True(t, tokenExpiry(unsignedJWT(`{"sub":"client_1"}`)).IsZero())
+	require.True(t, tokenExpiry(unsignedJWT(`not json`)).IsZero())
+	require.True(t, tokenExpiry("header.!!!.signature").IsZero())
+	require.True(t, tokenExpiry("opaque-token").IsZero())
+}
+
+func TestManagementAPIToken(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "unexpired", token: unsignedJWT(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(time.Hour).Unix()))},
+		{name: "without expiry", token: "opaque-token"},
+		{name: "expired", token: unsignedJWT(fmt.Sprintf(`{"exp":%d}`, time.Now().Add(-time.Minute).Unix())), err: ErrTokenRejected},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant := &authServer{}
+			server := httptest.NewServer(tenant)
+			defer server.Close()
+
+			c, err := New(ctx, server.URL, "", "", WithManagementAPIToken(test.token))
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			_, _, err = c.GetUser(ctx, "auth0|1")
+			require.NoError(t, err)
+			require.Empty(t, tenant.tokenRequests)
+			require.Equal(t, []string{"Bearer " + test.token}, tenant.authorization)
+		})
+	}
+}
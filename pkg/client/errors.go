@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// APIError is an error response of the Management API. See
+// https://auth0.com/docs/api/management/v2#errors.
+type APIError struct {
+	StatusCode int    `json:"statusCode"`
+	Err        string `json:"error"`
+	Message    string `json:"message"`
+	// ErrorCode is a more specific reason for the error, like insufficient_scope or
+	// inexistent_user. Not every error has one.
+	ErrorCode string `json:"errorCode"`
+
+	err error
+}
+
+// newAPIError parses the error response of a request. A body that is not a JSON
+// error is kept as the message.
+func newAPIError(response *http.Response, body string, err error) *APIError {
+	apiError := &APIError{}
+	if json.Unmarshal([]byte(body), apiError) != nil || apiError.Message == "" && apiError.Err == "" {
+		apiError = &APIError{Message: body}
+	}
+	apiError.err = err
+	if apiError.StatusCode == 0 {
+		apiError.StatusCode = response.StatusCode
+	}
+	if apiError.Err == "" {
+		apiError.Err = http.StatusText(response.StatusCode)
+	}
+
+	return apiError
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("baton-auth0: %d %s", e.StatusCode, e.Err)
+	if e.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, e.Message)
+	}
+	if e.ErrorCode != "" {
+		message = fmt.Sprintf("%s (%s)", message, e.ErrorCode)
+	}
+	return message
+}
+
+// Unwrap returns the error of the HTTP client, which carries the gRPC status of
+// the HTTP status code.
+func (e *APIError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIError(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		statusCode  int
+		contentType string
+		body        string
+		expected    APIError
+		message     string
+	}{
+		{
+			name:       "insufficient scope",
+			statusCode: http.StatusForbidden,
+			body:       `{"statusCode":403,"error":"Forbidden","message":"Insufficient scope, expected any of: read:users","errorCode":"insufficient_scope"}`,
+			expected: APIError{
+				StatusCode: 403,
+				Err:        "Forbidden",
+				Message:    "Insufficient scope, expected any of: read:users",
+				ErrorCode:  "insufficient_scope",
+			},
+			message: "baton-auth0: 403 Forbidden: Insufficient scope, expected any of: read:users (insufficient_scope)",
+		},
+		{
+			name:       "inexistent user",
+			statusCode: http.StatusNotFound,
+			body:       `{"statusCode":404,"error":"Not Found","message":"The user does not exist.","errorCode":"inexistent_user"}`,
+			expected: APIError{
+				StatusCode: 404,
+				Err:        "Not Found",
+				Message:    "The user does not exist.",
+				ErrorCode:  "inexistent_user",
+			},
+			message: "baton-auth0: 404 Not Found: The user does not exist. (inexistent_user)",
+		},
+		{
+			name:       "invalid query",
+			statusCode: http.StatusBadRequest,
+			body:       `{"statusCode":400,"error":"Bad Request","message":"Query validation error: 'Invalid value \"x\"' on property per_page.","errorCode":"invalid_query_string"}`,
+			expected: APIError{
+				StatusCode: 400,
+				Err:        "Bad Request",
+				Message:    `Query validation error: 'Invalid value "x"' on property per_page.`,
+				ErrorCode:  "invalid_query_string",
+			},
+			message: `baton-auth0: 400 Bad Request: Query validation error: 'Invalid value "x"' on property per_page. (invalid_query_string)`,
+		},
+		{
+			name:       "conflict without error code",
+			statusCode: http.StatusConflict,
+			body:       `{"statusCode":409,"error":"Conflict","message":"The user already exists."}`,
+			expected: APIError{
+				StatusCode: 409,
+				Err:        "Conflict",
+				Message:    "The user already exists.",
+			},
+			message: "baton-auth0: 409 Conflict: The user already exists.",
+		},
+		{
+			name:        "HTML body",
+			statusCode:  http.StatusBadGateway,
+			contentType: "text/html",
+			body:        "<html><body>Bad Gateway</body></html>",
+			expected: APIError{
+				StatusCode: 502,
+				Err:        "Bad Gateway",
+				Message:    "<html><body>Bad Gateway</body></html>",
+			},
+			message: "baton-auth0: 502 Bad Gateway: <html><body>Bad Gateway</body></html>",
+		},
+		{
+			name:       "JSON without error fields",
+			statusCode: http.StatusInternalServerError,
+			body:       `{"code":"unknown"}`,
+			expected: APIError{
+				StatusCode: 500,
+				Err:        "Internal Server Error",
+				Message:    `{"code":"unknown"}`,
+			},
+			message: `baton-auth0: 500 Internal Server Error: {"code":"unknown"}`,
+		},
+		{
+			name:       "empty body",
+			statusCode: http.StatusNotFound,
+			body:       "",
+			expected: APIError{
+				StatusCode: 404,
+				Err:        "Not Found",
+			},
+			message: "baton-auth0: 404 Not Found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/oauth/token" {
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"access_token":"mock-token","expires_in":86400}`))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				if test.contentType != "" {
+					w.Header().Set("Content-Type", test.contentType)
+				}
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			c, err := New(ctx, server.URL, "mock", "token")
+			require.NoError(t, err)
+
+			_, _, err = c.GetUser(ctx, "auth0|1")
+			var apiError *APIError
+			require.ErrorAs(t, err, &apiError)
+			require.Equal(t, test.expected.StatusCode, apiError.StatusCode)
+			require.Equal(t, test.expected.Err, apiError.Err)
+			require.Equal(t, test.expected.Message, apiError.Message)
+			require.Equal(t, test.expected.ErrorCode, apiError.ErrorCode)
+			require.Equal(t, test.message, apiError.Error())
+
+			// The error of the HTTP client stays available.
+			require.NotNil(t, errors.Unwrap(apiError))
+			require.NotEqual(t, codes.OK, status.Code(errors.Unwrap(apiError)))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+)
+
+const (
+	JobStatusPending    = "pending"
+	JobStatusProcessing = "processing"
+	JobStatusCompleted  = "completed"
+	JobStatusFailed     = "failed"
+
+	jobPollInterval = 5 * time.Second
+)
+
+// usersExportFields are the user attributes exported, the ones User holds.
+var usersExportFields = []UsersExportField{
+	{Name: "user_id"},
+	{Name: "email"},
+	{Name: "email_verified"},
+	{Name: "name"},
+	{Name: "nickname"},
+	{Name: "picture"},
+	{Name: "blocked"},
+	{Name: "created_at"},
+	{Name: "updated_at"},
+	{Name: "last_login"},
+	{Name: "identities"},
+	{Name: "given_name"},
+	{Name: "family_name"},
+	{Name: "username"},
+	{Name: "phone_number"},
+	{Name: "logins_count"},
+	{Name: "last_ip"},
+	{Name: "last_password_reset"},
+	{Name: "multifactor"},
+	{Name: "app_metadata"},
+	{Name: "user_metadata"},
+}
+
+// CreateUsersExport starts a job exporting every user of the tenant as NDJSON.
+func (c *Client) CreateUsersExport(ctx context.Context) (*Job, *v2.RateLimitDescription, error) {
+	var target Job
+	response, rateLimitData, err := c.post(
+		ctx,
+		apiPathUsersExports,
+		UsersExportRequest{Format: "json", Fields: usersExportFields},
+		&target,
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+// GetJob returns the status of a job. The response is never cached, since the
+// job keeps changing until it is done.
+func (c *Client) GetJob(ctx context.Context, jobId string) (*Job, *v2.RateLimitDescription, error) {
+	var target Job
+	response, rateLimitData, err := c.send(
+		ctx,
+		http.MethodGet,
+		c.BaseUrl.JoinPath(fmt.Sprintf(apiPathJob, jobId)),
+		nil,
+		[]uhttp.RequestOption{uhttp.WithNoCache()},
+		uhttp.WithJSONResponse(&target),
+	)
+	if err != nil {
+		return nil, rateLimitData, err
+	}
+
+	defer response.Body.Close()
+
+	return &target, rateLimitData, nil
+}
+
+// WaitForJob polls a job until it is done or maxWait passes, and returns its
+// last status.
+func (c *Client) WaitForJob(ctx context.Context, jobId string, maxWait time.Duration) (*Job, *v2.RateLimitDescription, error) {
+	deadline := time.Now().Add(maxWait)
+	for {
+		job, rateLimitData, err := c.GetJob(ctx, jobId)
+		if err != nil {
+			return nil, rateLimitData, err
+		}
+		if job.Status != JobStatusPending && job.Status != JobStatusProcessing {
+			return job, rateLimitData, nil
+		}
+
+		wait := min(jobPollInterval, time.Until(deadline))
+		if wait <= 0 {
+			return job, rateLimitData, nil
+		}
+		err = sleep(ctx, wait)
+		if err != nil {
+			return nil, rateLimitData, err
+		}
+	}
+}
+
+// DownloadUsersExport downloads the gzipped result of a users export and stores
+// it decompressed at path. The location is a pre-signed URL, so the request is
+// sent without the access token.
+func (c *Client) DownloadUsersExport(ctx context.Context, location string, path string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.wrapper.HttpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("baton-auth0: failed to download users export: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("baton-auth0: failed to download users export: %s", response.Status)
+	}
+
+	reader, err := gzip.NewReader(bufio.NewReader(response.Body))
+	if err != nil {
+		return fmt.Errorf("baton-auth0: invalid users export: %w", err)
+	}
+	defer reader.Close()
+
+	// Write to a temporary file first, so that an interrupted download is never
+	// mistaken for a complete one.
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("baton-auth0: failed to download users export: %w", err)
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
@@ -1,6 +1,9 @@
 package client
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type AuthRequest struct {
 	Audience     string `json:"audience"`
@@ -16,14 +19,160 @@ type AuthResponse struct {
 	TokenType   string `json:"token_type"`
 }
 
+// Application is an Auth0 application, which the Management API calls a client.
+type Application struct {
+	ClientId                string   `json:"client_id"`
+	Name                    string   `json:"name"`
+	Description             string   `json:"description"`
+	AppType                 string   `json:"app_type"`
+	IsFirstParty            bool     `json:"is_first_party"`
+	GrantTypes              []string `json:"grant_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+	LogoUri                 string   `json:"logo_uri"`
+}
+
+type ClientsResponse struct {
+	PaginatedResponse
+	Clients []Application `json:"clients"`
+}
+
+// ClientGrant gives an application access to a set of scopes on a resource server,
+// identified by its audience.
+type ClientGrant struct {
+	Id       string   `json:"id"`
+	ClientId string   `json:"client_id"`
+	Audience string   `json:"audience"`
+	Scope    []string `json:"scope"`
+}
+
+type ClientGrantsResponse struct {
+	PaginatedResponse
+	ClientGrants []ClientGrant `json:"client_grants"`
+}
+
+type Connection struct {
+	Id                 string   `json:"id"`
+	Name               string   `json:"name"`
+	DisplayName        string   `json:"display_name"`
+	Strategy           string   `json:"strategy"`
+	EnabledClients     []string `json:"enabled_clients"`
+	Realms             []string `json:"realms"`
+	IsDomainConnection bool     `json:"is_domain_connection"`
+	// Options are strategy specific. Only the database password policy is decoded.
+	Options *ConnectionOptions `json:"options,omitempty"`
+}
+
+// ConnectionClientStatus enables or disables one application on a connection.
+type ConnectionClientStatus struct {
+	ClientId string `json:"client_id"`
+	Status   bool   `json:"status"`
+}
+
+type ConnectionOptions struct {
+	// PasswordPolicy is one of none, low, fair, good or excellent.
+	PasswordPolicy            string                     `json:"passwordPolicy"`
+	PasswordComplexityOptions *PasswordComplexityOptions `json:"password_complexity_options"`
+}
+
+type PasswordComplexityOptions struct {
+	MinLength int `json:"min_length"`
+}
+
+type ConnectionsResponse struct {
+	PaginatedResponse
+	Connections []Connection `json:"connections"`
+}
+
+// Log is an entry of the tenant logs. Type is one of the codes listed in
+// https://auth0.com/docs/deploy-monitor/logs/log-event-type-codes.
+type Log struct {
+	LogId        string    `json:"log_id"`
+	Date         time.Time `json:"date"`
+	Type         string    `json:"type"`
+	Description  string    `json:"description"`
+	ClientId     string    `json:"client_id"`
+	ClientName   string    `json:"client_name"`
+	Connection   string    `json:"connection"`
+	ConnectionId string    `json:"connection_id"`
+	UserId       string    `json:"user_id"`
+	UserName     string    `json:"user_name"`
+	Ip           string    `json:"ip"`
+	// Details depend on the log type. For Management API operations they hold the
+	// request and the response, see LogAPIOperation.
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+// LogAPIOperation is the details of a Management API operation log entry.
+type LogAPIOperation struct {
+	Request struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int             `json:"statusCode"`
+		Body       json.RawMessage `json:"body,omitempty"`
+	} `json:"response"`
+}
+
 type Organization struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	DisplayName string `json:"display_name"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// OrganizationMember is a user as listed among an organization's members, along
+// with the roles the user has within that organization.
+type OrganizationMember struct {
+	User
+	Roles []Role `json:"roles"`
+}
+
+type OrganizationInvitation struct {
+	Id             string    `json:"id"`
+	OrganizationId string    `json:"organization_id"`
+	Inviter        Inviter   `json:"inviter"`
+	Invitee        Invitee   `json:"invitee"`
+	ClientId       string    `json:"client_id"`
+	ConnectionId   string    `json:"connection_id,omitempty"`
+	Roles          []string  `json:"roles,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type Inviter struct {
+	Name string `json:"name"`
+}
+
+// TenantSettings holds the settings of the tenant. Only its name is decoded.
+type TenantSettings struct {
+	FriendlyName string `json:"friendly_name"`
+}
+
+type Invitee struct {
+	Email string `json:"email"`
+}
+
+// OrganizationConnection is a connection enabled on an organization.
+type OrganizationConnection struct {
+	ConnectionId string `json:"connection_id"`
+	// AssignMembershipOnLogin makes every user logging in through the connection a
+	// member of the organization.
+	AssignMembershipOnLogin bool `json:"assign_membership_on_login"`
+	Connection              struct {
+		Name     string `json:"name"`
+		Strategy string `json:"strategy"`
+	} `json:"connection"`
+}
+
+type OrganizationConnectionsResponse struct {
+	PaginatedResponse
+	EnabledConnections []OrganizationConnection `json:"enabled_connections"`
 }
 
 type OrganizationMembersResponse struct {
-	Members []User `json:"members"`
+	Members []OrganizationMember `json:"members"`
 	PaginatedResponse
 }
 
@@ -59,17 +208,43 @@ type RolesUsersCheckpointResponse struct {
 }
 
 type User struct {
-	Blocked       bool             `json:"blocked"`
-	CreatedAt     time.Time        `json:"created_at"`
-	Email         string           `json:"email"`
-	EmailVerified bool             `json:"email_verified"`
-	Identities    []UserIdentities `json:"identities"`
-	LastLogin     *time.Time       `json:"last_login"`
-	Name          string           `json:"name"`
-	Nickname      string           `json:"nickname"`
-	Picture       string           `json:"picture"`
-	UpdatedAt     time.Time        `json:"updated_at"`
-	UserId        string           `json:"user_id"`
+	AppMetadata       map[string]interface{} `json:"app_metadata"`
+	Blocked           bool                   `json:"blocked"`
+	CreatedAt         time.Time              `json:"created_at"`
+	Email             string                 `json:"email"`
+	EmailVerified     bool                   `json:"email_verified"`
+	FamilyName        string                 `json:"family_name"`
+	GivenName         string                 `json:"given_name"`
+	Identities        []UserIdentities       `json:"identities"`
+	LastIP            string                 `json:"last_ip"`
+	LastLogin         *time.Time             `json:"last_login"`
+	LastPasswordReset *time.Time             `json:"last_password_reset"`
+	LoginsCount       int                    `json:"logins_count"`
+	Multifactor       []string               `json:"multifactor"`
+	Name              string                 `json:"name"`
+	Nickname          string                 `json:"nickname"`
+	PhoneNumber       string                 `json:"phone_number"`
+	Picture           string                 `json:"picture"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+	UserId            string                 `json:"user_id"`
+	UserMetadata      map[string]interface{} `json:"user_metadata"`
+	Username          string                 `json:"username"`
+}
+
+// CreateUserRequest is the body used to create a user in a connection. Database
+// connections require a password.
+type CreateUserRequest struct {
+	Connection  string `json:"connection"`
+	Email       string `json:"email,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	VerifyEmail bool   `json:"verify_email"`
+}
+
+// PasswordChangeTicket holds the URL a user follows to set their own password.
+type PasswordChangeTicket struct {
+	Ticket string `json:"ticket"`
 }
 
 type UserIdentities struct {
@@ -117,3 +292,29 @@ type RolePermission struct {
 	ResourceServerName       string `json:"resource_server_name"`
 	ResourceServerIdentifier string `json:"resource_server_identifier"`
 }
+
+// UserPermissionsResponse is the response shape for the permissions assigned directly
+// to a user, which share the shape of role permissions.
+type UserPermissionsResponse struct {
+	PaginatedResponse
+	Permissions []RolePermission `json:"permissions"`
+}
+
+// Job is an asynchronous job of the Management API, like a users export.
+type Job struct {
+	Id     string `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// Location is the URL of the result of a completed users export.
+	Location string `json:"location,omitempty"`
+}
+
+// UsersExportField is a user attribute included in a users export.
+type UsersExportField struct {
+	Name string `json:"name"`
+}
+
+type UsersExportRequest struct {
+	Format string             `json:"format"`
+	Fields []UsersExportField `json:"fields"`
+}
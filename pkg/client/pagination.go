@@ -2,6 +2,8 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/conductorone/baton-sdk/pkg/pagination"
@@ -14,40 +16,142 @@ const PageSizeDefault = 100
 // See https://auth0.com/docs/manage-users/user-search/view-search-results-by-page#limitation.
 const Auth0UserSearchMaxResults = 1000
 
+// userDatePrecision is the precision of the created_at and updated_at dates of
+// users.
+const userDatePrecision = time.Millisecond
+
+// userSearchLag is how far behind the user search index may lag. Listings of
+// updated users start again this long before the end of the previous window, in
+// case the index didn't have the updates made right before it yet.
+const userSearchLag = 5 * time.Minute
+
+// The user dates users are listed in windows of.
+const (
+	UsersFieldCreatedAt = "created_at"
+	UsersFieldUpdatedAt = "updated_at"
+)
+
+// User search sort orders.
+const (
+	UsersSortCreatedAt = UsersFieldCreatedAt + ":1"
+	UsersSortUserId    = "user_id:1"
+)
+
 type Pagination struct {
 	PagingRequestId string `json:"pagingRequestId"`
 	Page            int    `json:"page"`
 }
 
+// UserPagination pages through the users created up to Until, the time the sync
+// started, so that users created during the sync don't shift the pages. Since
+// Auth0's user search returns at most 1,000 results, users are listed in windows
+// of created_at dates: once a window runs out, the next one starts at the newest
+// created_at seen so far.
+//
+// Listings of updated users page through the users updated since a high-water
+// mark instead, in windows of updated_at dates. Their first token holds the mark
+// as Since, and no Until, so that the window ends when its first page is fetched.
 type UserPagination struct {
-	Page                int        `json:"page"`
-	Since               string     `json:"since,omitempty"`
-	Until               string     `json:"until,omitempty"`
-	NewestUserCreatedAt *time.Time `json:"newestUserCreatedAt,omitempty"`
+	Page int `json:"page"`
+	// Field is the user date windows are made of, created_at if empty.
+	Field string `json:"field,omitempty"`
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+	// Tied windows only hold the users whose date is Since, sorted by user ID, and
+	// with a user ID after AfterUserId if set. They are used when more users than a
+	// window can hold share that date.
+	Tied        bool   `json:"tied,omitempty"`
+	AfterUserId string `json:"afterUserId,omitempty"`
+	// SkipUserIds are the users dated Since that a previous window already
+	// returned, sorted by user ID. Windows include their Since date, so that users
+	// sharing a date across the window boundary are not skipped.
+	SkipUserIds []string `json:"skipUserIds,omitempty"`
+	// NewestUserDate is the newest date seen so far, and NewestUserIds the users
+	// dated at it.
+	NewestUserDate *time.Time `json:"newestUserDate,omitempty"`
+	NewestUserIds  []string   `json:"newestUserIds,omitempty"`
+}
+
+// NewUpdatedUsersToken returns the pagination token listing the users updated
+// from since, a high-water mark, up to when its first page is fetched.
+func NewUpdatedUsersToken(since time.Time) (string, error) {
+	return marshalUsersToken(UserPagination{
+		Field: UsersFieldUpdatedAt,
+		Since: since.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// NextHighWaterMark returns the high-water mark the next listing of updated users
+// starts from once this window is listed: its end, less the search index lag.
+func (p UserPagination) NextHighWaterMark() (time.Time, error) {
+	until, err := time.Parse(time.RFC3339Nano, p.Until)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until.Add(-userSearchLag), nil
 }
 
-// ParseUserPaginationToken - takes as pagination token and returns page, limit,
-// also it includes since and until dates to search users, and the date the newest user was created.
+// Query returns the user search query for the window.
+func (p UserPagination) Query() string {
+	if !p.Tied {
+		return fmt.Sprintf("%s:[%s TO %s]", p.field(), p.Since, p.Until)
+	}
+
+	query := fmt.Sprintf("%s:[%s TO %s]", p.field(), p.Since, p.Since)
+	if p.AfterUserId != "" {
+		query += fmt.Sprintf(" AND user_id:{%s TO *]", QuoteSearchValue(p.AfterUserId))
+	}
+	return query
+}
+
+// Sort returns the user search sort order for the window.
+func (p UserPagination) Sort() string {
+	if p.Tied {
+		return UsersSortUserId
+	}
+	return p.field() + ":1"
+}
+
+func (p UserPagination) field() string {
+	if p.Field == "" {
+		return UsersFieldCreatedAt
+	}
+	return p.Field
+}
+
+// date returns the date of the user that windows are made of.
+func (p UserPagination) date(user User) time.Time {
+	if p.field() == UsersFieldUpdatedAt {
+		return user.UpdatedAt.UTC()
+	}
+	return user.CreatedAt.UTC()
+}
+
+// Skip reports whether a previous window already returned the user.
+func (p UserPagination) Skip(userId string) bool {
+	_, found := slices.BinarySearch(p.SkipUserIds, userId)
+	return found
+}
+
+// ParseUserPaginationToken - takes as pagination token and returns the window and
+// page of users to search, and the limit.
 func ParseUserPaginationToken(pToken *pagination.Token) (
+	UserPagination,
 	int,
-	int,
-	string,
-	string,
-	*time.Time,
 	error,
 ) {
 	var (
-		limit = PageSizeDefault
-		page  = 0
-		since = "*"
-
-		// Until never gets updated. Always should use the last possible date.
-		until = time.Now().UTC().Format(time.RFC3339Nano)
+		limit  = PageSizeDefault
+		parsed = UserPagination{
+			Since: "*",
+			// Until never gets updated, so that it is the same for the whole sync.
+			// Tokens without one end their window now.
+			Until: time.Now().UTC().Format(time.RFC3339Nano),
+		}
 	)
-	var newestUserCreationDate *time.Time
 
 	if pToken == nil {
-		return page, limit, since, until, newestUserCreationDate, nil
+		return parsed, limit, nil
 	}
 
 	if pToken.Size > 0 {
@@ -55,22 +159,54 @@ func ParseUserPaginationToken(pToken *pagination.Token) (
 	}
 
 	if pToken.Token == "" {
-		return page, limit, since, until, newestUserCreationDate, nil
+		return parsed, limit, nil
 	}
 
-	var parsed UserPagination
 	err := json.Unmarshal([]byte(pToken.Token), &parsed)
 	if err != nil {
-		return 0, 0, "", "", nil, err
+		return UserPagination{}, 0, err
 	}
 
-	page = parsed.Page
-	newestUserCreationDate = parsed.NewestUserCreatedAt
-	if parsed.Since != "" {
-		since = parsed.Since
+	return parsed, limit, nil
+}
+
+// UsersExportPagination pages through the users of a users export job.
+type UsersExportPagination struct {
+	JobId string `json:"jobId,omitempty"`
+	// Line is the number of users already read from the export, and Offset where
+	// they end in the downloaded export.
+	Line   int   `json:"line"`
+	Offset int64 `json:"offset"`
+}
+
+// ParseUsersExportToken - takes as pagination token and returns the users export
+// position and the limit.
+func ParseUsersExportToken(pToken *pagination.Token) (
+	UsersExportPagination,
+	int,
+	error,
+) {
+	limit := PageSizeDefault
+	var parsed UsersExportPagination
+
+	if pToken == nil {
+		return parsed, limit, nil
+	}
+
+	if pToken.Size > 0 {
+		limit = pToken.Size
+	}
+
+	if pToken.Token == "" {
+		return parsed, limit, nil
 	}
 
-	return page, limit, since, until, newestUserCreationDate, nil
+	err := json.Unmarshal([]byte(pToken.Token), &parsed)
+	if err != nil {
+		return UsersExportPagination{}, 0, err
+	}
+
+	return parsed, limit, nil
 }
 
 // ParsePaginationToken - takes as pagination token and returns page, limit,
@@ -194,38 +330,129 @@ func GetNextToken(
 	return string(bytes)
 }
 
-func GetNextUsersToken(
+// GetNextTokenWithoutTotal is GetNextToken for endpoints that don't report a total
+// number of resources: a page with fewer than limit results is the last one.
+func GetNextTokenWithoutTotal(
 	page int,
 	limit int,
+	count int,
+) string {
+	if count < limit {
+		return ""
+	}
+
+	bytes, err := json.Marshal(Pagination{
+		Page: page + 1,
+	})
+	if err != nil {
+		return ""
+	}
+
+	return string(bytes)
+}
+
+// GetNextUsersToken given the window and limit that were used to fetch _this_
+// page of users, the total number of users in the window and the users, returns
+// the next pagination token as a string.
+func GetNextUsersToken(
+	current UserPagination,
+	limit int,
 	total int,
-	since string,
-	newestCreatedAt *time.Time,
+	users []User,
 ) (string, error) {
-	nextPage := page + 1
-	nextOffset := nextPage * limit
+	if current.Tied {
+		return getNextTiedUsersToken(current, limit, total, users)
+	}
 
-	if nextOffset >= Auth0UserSearchMaxResults {
-		if newestCreatedAt == nil {
-			return "", nil
+	next := current
+	next.Page++
+	next.NewestUserIds = slices.Clone(current.NewestUserIds)
+	for _, user := range users {
+		date := current.date(user)
+		switch {
+		case next.NewestUserDate == nil || date.After(*next.NewestUserDate):
+			next.NewestUserDate = &date
+			next.NewestUserIds = []string{user.UserId}
+		case date.Equal(*next.NewestUserDate) && !slices.Contains(next.NewestUserIds, user.UserId):
+			next.NewestUserIds = append(next.NewestUserIds, user.UserId)
 		}
+	}
+	slices.Sort(next.NewestUserIds)
 
-		nextSince := newestCreatedAt.UTC().Format(time.RFC3339Nano)
-		if nextSince == since {
-			// No forward progress — stop to avoid infinite loop
+	nextOffset := next.Page * limit
+	if nextOffset >= total {
+		return "", nil
+	}
+
+	// Users updated during the sync leave windows of updated_at dates, which would
+	// shift the following pages, so those windows move on after every page.
+	if nextOffset+limit > Auth0UserSearchMaxResults || current.field() == UsersFieldUpdatedAt {
+		if next.NewestUserDate == nil {
 			return "", nil
 		}
-		
-		nextPage = 0
-		since = nextSince
-	} else if nextOffset >= total {
-		return "", nil
+
+		next.Page = 0
+		next.SkipUserIds = next.NewestUserIds
+		nextSince := next.NewestUserDate.Format(time.RFC3339Nano)
+		if nextSince == current.Since {
+			// The whole window is dated Since, so the users dated at it are listed
+			// by user ID instead, which can go past the search limit.
+			next.Tied = true
+			next.AfterUserId = ""
+		} else {
+			next.Since = nextSince
+		}
 	}
 
-	bytes, err := json.Marshal(UserPagination{
-		Page:                nextPage,
-		Since:               since,
-		NewestUserCreatedAt: newestCreatedAt,
-	})
+	return marshalUsersToken(next)
+}
+
+// getNextTiedUsersToken is GetNextUsersToken for tied windows. Once all the users
+// dated Since are listed, the next window starts right after Since.
+func getNextTiedUsersToken(
+	current UserPagination,
+	limit int,
+	total int,
+	users []User,
+) (string, error) {
+	next := current
+	next.Page++
+
+	nextOffset := next.Page * limit
+	switch {
+	case nextOffset >= total:
+		since, err := time.Parse(time.RFC3339Nano, current.Since)
+		if err != nil {
+			return "", err
+		}
+		next.Page = 0
+		next.Since = since.Add(userDatePrecision).Format(time.RFC3339Nano)
+		next.Tied = false
+		next.AfterUserId = ""
+		next.SkipUserIds = nil
+		next.NewestUserIds = nil
+	case len(users) > 0 && (nextOffset+limit > Auth0UserSearchMaxResults || current.field() == UsersFieldUpdatedAt):
+		// Users are sorted by user ID, so the last one has the greatest.
+		next.Page = 0
+		next.AfterUserId = users[len(users)-1].UserId
+	}
+
+	return marshalUsersToken(next)
+}
+
+func marshalUsersToken(next UserPagination) (string, error) {
+	bytes, err := json.Marshal(next)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// GetNextUsersExportToken returns the pagination token resuming a users export at
+// position.
+func GetNextUsersExportToken(position UsersExportPagination) (string, error) {
+	bytes, err := json.Marshal(position)
 	if err != nil {
 		return "", err
 	}
@@ -6,17 +6,38 @@ import (
 )
 
 const (
-	apiPathAuth                = "/oauth/token"
-	apiPathBase                = "/api/v2/" // Note: trailing slash is required by audience.
-	apiPathOrganizationMembers = "/api/v2/organizations/%s/members"
-	apiPathGetOrganizations    = "/api/v2/organizations"
-	apiPathGetRoles            = "/api/v2/roles"
-	apiPathGetUsers            = "/api/v2/users"
-	apiPathRolesForUser        = "/api/v2/users/%s/roles"
-	apiPathUsersForRole        = "/api/v2/roles/%s/users"
-	apiPathGetResourceServers  = "/api/v2/resource-servers"
-	apiPathResourceServers     = "/api/v2/resource-servers/%s"
-	apiPathRolePermissions     = "/api/v2/roles/%s/permissions"
+	apiPathAuth                    = "/oauth/token"
+	apiPathBase                    = "/api/v2/" // Note: trailing slash is required by audience.
+	apiPathOrganizationMembers     = "/api/v2/organizations/%s/members"
+	apiPathGetOrganizations        = "/api/v2/organizations"
+	apiPathGetRoles                = "/api/v2/roles"
+	apiPathGetUsers                = "/api/v2/users"
+	apiPathRolesForUser            = "/api/v2/users/%s/roles"
+	apiPathUsersForRole            = "/api/v2/roles/%s/users"
+	apiPathGetResourceServers      = "/api/v2/resource-servers"
+	apiPathResourceServers         = "/api/v2/resource-servers/%s"
+	apiPathRolePermissions         = "/api/v2/roles/%s/permissions"
+	apiPathGetClients              = "/api/v2/clients"
+	apiPathGetClientGrants         = "/api/v2/client-grants"
+	apiPathClientGrant             = "/api/v2/client-grants/%s"
+	apiPathUserPermissions         = "/api/v2/users/%s/permissions"
+	apiPathOrganizationMemberRoles = "/api/v2/organizations/%s/members/%s/roles"
+	apiPathGetConnections          = "/api/v2/connections"
+	apiPathConnection              = "/api/v2/connections/%s"
+	apiPathConnectionClients       = "/api/v2/connections/%s/clients"
+	apiPathOrganizationInvitations = "/api/v2/organizations/%s/invitations"
+	apiPathUser                    = "/api/v2/users/%s"
+	apiPathOrganizationConnections = "/api/v2/organizations/%s/enabled_connections"
+	apiPathOrganizationConnection  = "/api/v2/organizations/%s/enabled_connections/%s"
+	apiPathUsersByEmail            = "/api/v2/users-by-email"
+	apiPathPasswordChangeTicket    = "/api/v2/tickets/password-change"
+	apiPathGetLogs                 = "/api/v2/logs"
+	apiPathLog                     = "/api/v2/logs/%s"
+	apiPathRole                    = "/api/v2/roles/%s"
+	apiPathOrganization            = "/api/v2/organizations/%s"
+	apiPathUsersExports            = "/api/v2/jobs/users-exports"
+	apiPathJob                     = "/api/v2/jobs/%s"
+	apiPathTenantSettings          = "/api/v2/tenants/settings"
 )
 
 func (c *Client) getUrl(
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/ratelimit"
+)
+
+const (
+	// rateLimitRetries is how many times a request is retried when it is rate limited
+	// or the API is unavailable.
+	rateLimitRetries = 5
+	retryBackoffBase = time.Second
+	retryBackoffMax  = 30 * time.Second
+	// Once fewer than this share of the tenant's budget is left, requests are spread
+	// over the time left until the budget resets instead of spending it right away.
+	rateLimitLowWatermark = 0.2
+)
+
+// rateLimiter paces the requests of a client, which all share the tenant's rate
+// limit. It learns the limit from the responses and slows down before running out,
+// and never exceeds the configured requests per second.
+type rateLimiter struct {
+	mu sync.Mutex
+	// interval is the minimum time between requests, zero when there is no ceiling.
+	interval time.Duration
+	next     time.Time
+
+	limit     int64
+	remaining int64
+	resetAt   time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	limiter := &rateLimiter{}
+	if requestsPerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return limiter
+}
+
+// Wait blocks until the next request can be sent.
+func (r *rateLimiter) Wait(ctx context.Context) error {
+	return sleep(ctx, r.reserve(time.Now()))
+}
+
+// reserve takes a slot for a request at now and returns how long to wait for it.
+func (r *rateLimiter) reserve(now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at := now
+	if r.next.After(at) {
+		at = r.next
+	}
+	interval := r.interval
+
+	if r.limit > 0 && at.Before(r.resetAt) {
+		switch {
+		case r.remaining <= 0:
+			at = r.resetAt
+		case float64(r.remaining) < float64(r.limit)*rateLimitLowWatermark:
+			interval = max(interval, r.resetAt.Sub(at)/time.Duration(r.remaining))
+		}
+		// Count the request against the budget until a response reports it.
+		r.remaining--
+	}
+
+	r.next = at.Add(interval)
+	return at.Sub(now)
+}
+
+// Update records the budget reported by a response.
+func (r *rateLimiter) Update(rateLimitData *v2.RateLimitDescription) {
+	if rateLimitData == nil || rateLimitData.GetLimit() <= 0 || !rateLimitData.HasResetAt() {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.limit = rateLimitData.GetLimit()
+	r.remaining = rateLimitData.GetRemaining()
+	r.resetAt = rateLimitData.GetResetAt().AsTime()
+}
+
+// rateLimitObserver is a transport that records the budget reported by the
+// Management API responses in the rate limiter. Responses served from the HTTP
+// cache never reach it, so their stale headers can't reset the budget.
+type rateLimitObserver struct {
+	next    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func newRateLimitObserver(next http.RoundTripper, limiter *rateLimiter) *rateLimitObserver {
+	return &rateLimitObserver{next: next, limiter: limiter}
+}
+
+func (t *rateLimitObserver) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token endpoint has a budget of its own.
+	if strings.HasPrefix(request.URL.Path, apiPathBase) {
+		rateLimitData, err := ratelimit.ExtractRateLimitData(response.StatusCode, &response.Header)
+		if err == nil {
+			t.limiter.Update(rateLimitData)
+		}
+	}
+
+	return response, nil
+}
+
+// isRetryableResponse reports whether a request failed because it was rate limited
+// or the API was temporarily unavailable.
+func isRetryableResponse(response *http.Response) bool {
+	return response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode == http.StatusServiceUnavailable
+}
+
+// retryDelay returns how long to wait before retrying a request: the time given
+// by the Retry-After header if any, and otherwise an exponential backoff with full
+// jitter.
+func retryDelay(response *http.Response, attempt int) time.Duration {
+	if retryAfter := response.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(time.Until(at), 0)
+		}
+	}
+
+	backoff := min(retryBackoffBase<<attempt, retryBackoffMax)
+	return rand.N(backoff) //nolint:gosec // Jitter doesn't need a secure random number.
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func rateLimitData(limit int64, remaining int64, resetAt time.Time) *v2.RateLimitDescription {
+	return v2.RateLimitDescription_builder{
+		Limit:     limit,
+		Remaining: remaining,
+		ResetAt:   timestamppb.New(resetAt),
+	}.Build()
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("no budget known", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		require.Zero(t, limiter.reserve(now))
+		require.Zero(t, limiter.reserve(now))
+	})
+
+	t.Run("requests per second", func(t *testing.T) {
+		limiter := newRateLimiter(4)
+		require.Zero(t, limiter.reserve(now))
+		require.Equal(t, 250*time.Millisecond, limiter.reserve(now))
+		require.Equal(t, 500*time.Millisecond, limiter.reserve(now))
+		// Slots that passed aren't made up for.
+		require.Zero(t, limiter.reserve(now.Add(time.Second)))
+	})
+
+	t.Run("plenty of budget", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		limiter.Update(rateLimitData(100, 50, now.Add(10*time.Second)))
+		require.Zero(t, limiter.reserve(now))
+		require.Zero(t, limiter.reserve(now))
+		// Reserved requests count against the budget until a response reports it.
+		require.EqualValues(t, 48, limiter.remaining)
+	})
+
+	t.Run("low budget", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		limiter.Update(rateLimitData(100, 10, now.Add(10*time.Second)))
+		// The requests left are spread over the time left until the reset.
+		require.Zero(t, limiter.reserve(now))
+		require.Equal(t, time.Second, limiter.reserve(now))
+		require.EqualValues(t, 8, limiter.remaining)
+	})
+
+	t.Run("budget spent", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		limiter.Update(rateLimitData(100, 0, now.Add(10*time.Second)))
+		require.Equal(t, 10*time.Second, limiter.reserve(now))
+	})
+
+	t.Run("budget reset", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		limiter.Update(rateLimitData(100, 0, now.Add(-time.Second)))
+		require.Zero(t, limiter.reserve(now))
+		require.EqualValues(t, 0, limiter.remaining)
+	})
+
+	t.Run("incomplete rate limit data", func(t *testing.T) {
+		limiter := newRateLimiter(0)
+		limiter.Update(nil)
+		limiter.Update(v2.RateLimitDescription_builder{Limit: 100, Remaining: 0}.Build())
+		require.Zero(t, limiter.limit)
+		require.Zero(t, limiter.reserve(now))
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	response := func(retryAfter string) *http.Response {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: header}
+	}
+
+	t.Run("Retry-After seconds", func(t *testing.T) {
+		require.Equal(t, 7*time.Second, retryDelay(response("7"), 0))
+		require.Equal(t, 7*time.Second, retryDelay(response("7"), 4))
+	})
+
+	t.Run("Retry-After date", func(t *testing.T) {
+		delay := retryDelay(response(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)), 0)
+		require.Greater(t, delay, 58*time.Second)
+		require.LessOrEqual(t, delay, time.Minute)
+
+		require.Zero(t, retryDelay(response(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)), 0))
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		for _, retryAfter := range []string{"", "soon", "-1"} {
+			for attempt, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+				delay := retryDelay(response(retryAfter), attempt)
+				require.GreaterOrEqual(t, delay, time.Duration(0))
+				require.Less(t, delay, backoff)
+			}
+			require.Less(t, retryDelay(response(retryAfter), 10), retryBackoffMax)
+		}
+	})
+}
+
+// rateLimitServer reports a budget that goes down with every API request.
+type rateLimitServer struct {
+	mu        sync.Mutex
+	remaining int
+	resetAt   time.Time
+}
+
+func (s *rateLimitServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/oauth/token" {
+		// The token endpoint's budget isn't the Management API's.
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.resetAt.Unix(), 10))
+		_ = json.NewEncoder(w).Encode(AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+		return
+	}
+
+	s.remaining--
+	w.Header().Set("X-RateLimit-Limit", "100")
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.resetAt.Unix(), 10))
+	_ = json.NewEncoder(w).Encode(User{UserId: "auth0|1"})
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(&rateLimitServer{remaining: 51, resetAt: time.Now().Add(time.Minute)})
+	defer server.Close()
+
+	client, err := New(ctx, server.URL, "mock", "token")
+	require.NoError(t, err)
+	require.Zero(t, client.limiter.limit)
+
+	_, _, err = client.GetUser(ctx, "auth0|1")
+	require.NoError(t, err)
+	require.EqualValues(t, 100, client.limiter.limit)
+	require.EqualValues(t, 50, client.limiter.remaining)
+
+	// A response served from the cache reports the budget it was fetched with,
+	// which the request didn't spend, so the budget counted down stays.
+	_, _, err = client.GetUser(ctx, "auth0|1")
+	require.NoError(t, err)
+	require.EqualValues(t, 49, client.limiter.remaining)
+
+	_, _, err = client.GetUser(ctx, "auth0|2")
+	require.NoError(t, err)
+	require.EqualValues(t, 49, client.limiter.remaining)
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
 // WithBearerToken - TODO(marcos): move this function to `baton-sdk`.
@@ -35,6 +38,53 @@ func (c *Client) get(
 	)
 }
 
+// getNoCache is like get, but always reads from the API instead of the response
+// cache, for resources that are read before changing them or polled for changes.
+func (c *Client) getNoCache(
+	ctx context.Context,
+	path string,
+	target interface{},
+	queryParameters []ReqOpt,
+) (
+	*http.Response,
+	*v2.RateLimitDescription,
+	error,
+) {
+	urlAddress := c.BaseUrl.JoinPath(path)
+	for _, opt := range queryParameters {
+		opt(urlAddress)
+	}
+
+	return c.send(
+		ctx,
+		http.MethodGet,
+		urlAddress,
+		nil,
+		[]uhttp.RequestOption{uhttp.WithNoCache()},
+		uhttp.WithJSONResponse(target),
+	)
+}
+
+func (c *Client) post(
+	ctx context.Context,
+	path string,
+	body interface{},
+	target interface{},
+) (
+	*http.Response,
+	*v2.RateLimitDescription,
+	error,
+) {
+	return c.doRequest(
+		ctx,
+		http.MethodPost,
+		path,
+		body,
+		target,
+		nil,
+	)
+}
+
 func (c *Client) postNoJSONResponse(
 	ctx context.Context,
 	path string,
@@ -53,6 +103,24 @@ func (c *Client) postNoJSONResponse(
 	)
 }
 
+func (c *Client) patchNoJSONResponse(
+	ctx context.Context,
+	path string,
+	body interface{},
+) (
+	*http.Response,
+	*v2.RateLimitDescription,
+	error,
+) {
+	return c.doRequestNoJSONResponse(
+		ctx,
+		http.MethodPatch,
+		path,
+		nil,
+		body,
+	)
+}
+
 func (c *Client) deleteNoJSONResponse(
 	ctx context.Context,
 	path string,
@@ -83,39 +151,12 @@ func (c *Client) doRequest(
 	*v2.RateLimitDescription,
 	error,
 ) {
-	options := []uhttp.RequestOption{
-		uhttp.WithAcceptJSONHeader(),
-		WithBearerToken(c.BearerToken),
-	}
-	if payload != nil {
-		options = append(options, uhttp.WithJSONBody(payload))
-	}
-
 	urlAddress := c.BaseUrl.JoinPath(path)
 	for _, opt := range queryParameters {
 		opt(urlAddress)
 	}
 
-	request, err := c.wrapper.NewRequest(ctx, method, urlAddress, options...)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var rateLimitData v2.RateLimitDescription
-	response, err := c.wrapper.Do(
-		request,
-		uhttp.WithRatelimitData(&rateLimitData),
-		uhttp.WithJSONResponse(target),
-	)
-
-	if err != nil {
-		if response != nil {
-			return nil, &rateLimitData, fmt.Errorf("error doing request: %w, body: %v", err, logBody(response.Body))
-		}
-		return nil, &rateLimitData, fmt.Errorf("error doing request: %w", err)
-	}
-
-	return response, &rateLimitData, nil
+	return c.send(ctx, method, urlAddress, payload, nil, uhttp.WithJSONResponse(target))
 }
 
 func logBody(body io.ReadCloser) string {
@@ -139,33 +180,85 @@ func (c *Client) doRequestNoJSONResponse(
 	*v2.RateLimitDescription,
 	error,
 ) {
-	options := []uhttp.RequestOption{
-		uhttp.WithAcceptJSONHeader(),
-		WithBearerToken(c.BearerToken),
-	}
-	if payload != nil {
-		options = append(options, uhttp.WithJSONBody(payload))
-	}
-
 	url := c.getUrl(path, queryParameters)
 
-	request, err := c.wrapper.NewRequest(ctx, method, url, options...)
-	if err != nil {
-		return nil, nil, err
-	}
+	return c.send(ctx, method, url, payload, nil)
+}
 
-	var ratelimitData v2.RateLimitDescription
-	response, err := c.wrapper.Do(
-		request,
-		uhttp.WithRatelimitData(&ratelimitData),
-	)
+// send makes an authenticated request, once the rate limiter lets it through. If
+// the access token is rejected, because it expired or was revoked early, the
+// request is retried once with a new token. Requests that are rate limited or find
+// the API unavailable are retried after a delay.
+func (c *Client) send(
+	ctx context.Context,
+	method string,
+	url *url.URL,
+	payload interface{},
+	requestOptions []uhttp.RequestOption,
+	doOptions ...uhttp.DoOption,
+) (
+	*http.Response,
+	*v2.RateLimitDescription,
+	error,
+) {
+	tokenRetried := false
+	for attempt := 0; ; attempt++ {
+		token, err := c.token.Token(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	if err != nil {
-		if response != nil {
-			return nil, &ratelimitData, fmt.Errorf("error doing request: %w, body: %v", err, logBody(response.Body))
+		options := []uhttp.RequestOption{
+			uhttp.WithAcceptJSONHeader(),
+			WithBearerToken(token),
+		}
+		if payload != nil {
+			options = append(options, uhttp.WithJSONBody(payload))
+		}
+		options = append(options, requestOptions...)
+
+		request, err := c.wrapper.NewRequest(ctx, method, url, options...)
+		if err != nil {
+			return nil, nil, err
 		}
-		return nil, &ratelimitData, fmt.Errorf("error doing request: %w", err)
-	}
 
-	return response, &ratelimitData, nil
+		err = c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var rateLimitData v2.RateLimitDescription
+		response, err := c.wrapper.Do(
+			request,
+			append([]uhttp.DoOption{uhttp.WithRatelimitData(&rateLimitData)}, doOptions...)...,
+		)
+		if err == nil {
+			return response, &rateLimitData, nil
+		}
+		if response == nil {
+			return nil, &rateLimitData, fmt.Errorf("error doing request: %w", err)
+		}
+
+		body := logBody(response.Body)
+		if !tokenRetried && isInvalidTokenResponse(response, body) {
+			tokenRetried = true
+			c.token.Invalidate(token)
+			continue
+		}
+		if attempt < rateLimitRetries && isRetryableResponse(response) {
+			delay := retryDelay(response, attempt)
+			ctxzap.Extract(ctx).Debug(
+				"baton-auth0: retrying request",
+				zap.String("url", url.String()),
+				zap.Int("status_code", response.StatusCode),
+				zap.Duration("delay", delay),
+			)
+			err = sleep(ctx, delay)
+			if err != nil {
+				return nil, &rateLimitData, err
+			}
+			continue
+		}
+		return nil, &rateLimitData, newAPIError(response, body, err)
+	}
 }
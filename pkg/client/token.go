@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tokens are refreshed this long before they expire, so that a request never goes
+// out with a token that lapses in flight.
+const tokenRefreshMargin = 5 * time.Minute
+
+// invalidTokenError is the error Auth0 answers requests made with an expired or
+// revoked access token with.
+const invalidTokenError = "invalid_token"
+
+type accessToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// tokenSource holds the Management API access token shared by all requests of a
+// client and fetches a new one when it is about to expire.
+type tokenSource struct {
+	mu        sync.Mutex
+	current   accessToken
+	refreshAt time.Time
+	// rejected is the last token the API rejected, which is never reused from the
+	// cache.
+	rejected string
+	fetch    func(ctx context.Context) (accessToken, error)
+	cache    *tokenCache
+}
+
+func newTokenSource(fetch func(ctx context.Context) (accessToken, error)) *tokenSource {
+	return &tokenSource{fetch: fetch}
+}
+
+// Token returns the current access token, fetching a new one first if there is none
+// yet or it is about to expire. Concurrent callers wait for a single fetch.
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.value != "" && (s.refreshAt.IsZero() || time.Now().Before(s.refreshAt)) {
+		return s.current.value, nil
+	}
+
+	var token accessToken
+	var err error
+	if s.cache != nil {
+		token, err = s.cache.Fetch(ctx, s.rejected, s.fetch)
+	} else {
+		token, err = s.fetch(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	s.current = token
+	s.refreshAt = refreshTime(token.expiresAt)
+
+	return token.value, nil
+}
+
+// Invalidate drops the token so that the next call to Token fetches a new one. It
+// does nothing if the token was already replaced, so that requests failing with an
+// old token don't discard the one another request just fetched.
+func (s *tokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current.value == token {
+		s.rejected = token
+		s.current = accessToken{}
+		s.refreshAt = time.Time{}
+	}
+}
+
+// refreshTime returns when a token expiring at expiresAt should be refreshed. Tokens
+// without an expiry are never refreshed, and short-lived tokens are refreshed halfway
+// through their lifetime rather than right away.
+func refreshTime(expiresAt time.Time) time.Time {
+	if expiresAt.IsZero() {
+		return time.Time{}
+	}
+
+	margin := min(tokenRefreshMargin, time.Until(expiresAt)/2)
+	return expiresAt.Add(-margin)
+}
+
+// isInvalidTokenResponse reports whether a response rejected the access token the
+// request was sent with, as opposed to any other authorization failure. Auth0 says so
+// with the invalid_token error of the WWW-Authenticate header or of the body.
+func isInvalidTokenResponse(response *http.Response, body string) bool {
+	if response.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	if bearerError(response.Header.Get("WWW-Authenticate")) == invalidTokenError {
+		return true
+	}
+	apiError := newAPIError(response, body, nil)
+	return apiError.ErrorCode == invalidTokenError || apiError.Err == invalidTokenError
+}
+
+// bearerError returns the error parameter of a Bearer WWW-Authenticate challenge, as
+// defined by RFC 6750.
+func bearerError(challenge string) string {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if key == "error" {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer issues numbered access tokens and rejects API requests made with the
+// tokens it revoked.
+type tokenServer struct {
+	mu      sync.Mutex
+	issued  int
+	revoked map[string]bool
+	// reject writes the response to a request made with a revoked token.
+	reject func(w http.ResponseWriter)
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/oauth/token" {
+		s.issued++
+		_ = json.NewEncoder(w).Encode(AuthResponse{AccessToken: fmt.Sprintf("token-%d", s.issued), ExpiresIn: 86400})
+		return
+	}
+
+	if s.revoked[r.Header.Get("Authorization")[len("Bearer "):]] {
+		s.reject(w)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(User{UserId: "auth0|1"})
+}
+
+func TestTokenRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		reject    func(w http.ResponseWriter)
+		refreshed bool
+	}{
+		{
+			name: "invalid_token challenge",
+			reject: func(w http.ResponseWriter) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="auth0", error="invalid_token", error_description="Expired token"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"statusCode":401,"error":"Unauthorized","message":"Expired token received for JSON Web Token validation"}`))
+			},
+			refreshed: true,
+		},
+		{
+			name: "invalid_token error code",
+			reject: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"statusCode":401,"error":"Unauthorized","message":"Invalid token","errorCode":"invalid_token"}`))
+			},
+			refreshed: true,
+		},
+		{
+			name: "other authorization failure",
+			reject: func(w http.ResponseWriter) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="auth0", error="insufficient_scope", error_description="token lacks scope"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"statusCode":401,"error":"Unauthorized","message":"Insufficient scope, expected any of: read:users","errorCode":"insufficient_scope"}`))
+			},
+			refreshed: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant := &tokenServer{revoked: map[string]bool{"token-1": true}, reject: test.reject}
+			server := httptest.NewServer(tenant)
+			defer server.Close()
+
+			c, err := New(ctx, server.URL, "mock", "secret")
+			require.NoError(t, err)
+
+			user, _, err := c.GetUser(ctx, "auth0|1")
+			if !test.refreshed {
+				require.Error(t, err)
+				require.Equal(t, 1, tenant.issued)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "auth0|1", user.UserId)
+			require.Equal(t, 2, tenant.issued)
+		})
+	}
+
+	t.Run("refreshed once", func(t *testing.T) {
+		tenant := &tokenServer{
+			revoked: map[string]bool{"token-1": true, "token-2": true},
+			reject:  tests[0].reject,
+		}
+		server := httptest.NewServer(tenant)
+		defer server.Close()
+
+		c, err := New(ctx, server.URL, "mock", "secret")
+		require.NoError(t, err)
+
+		_, _, err = c.GetUser(ctx, "auth0|1")
+		require.Error(t, err)
+		require.Equal(t, 2, tenant.issued)
+	})
+}
+
+func TestTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	expiresIn := time.Hour
+	var fetches int
+	source := newTokenSource(func(context.Context) (accessToken, error) {
+		fetches++
+		return accessToken{value: fmt.Sprintf("token-%d", fetches), expiresAt: time.Now().Add(expiresIn)}, nil
+	})
+
+	token, err := source.Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	// A token within the refresh margin of its expiry is replaced.
+	source.refreshAt = time.Now().Add(-time.Second)
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token)
+
+	// Invalidating a token that was already replaced keeps the current one.
+	source.Invalidate("token-1")
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token)
+
+	source.Invalidate("token-2")
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-3", token)
+}
+
+func TestBearerError(t *testing.T) {
+	require.Equal(t, "invalid_token", bearerError(`Bearer realm="auth0", error="invalid_token", error_description="Expired token"`))
+	require.Equal(t, "insufficient_scope", bearerError(`Bearer error_description="invalid_token", error="insufficient_scope"`))
+	require.Empty(t, bearerError(`Bearer realm="auth0"`))
+	require.Empty(t, bearerError(`Basic error="invalid_token"`))
+	require.Empty(t, bearerError(""))
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	tokenCacheKeyInfo = "baton-auth0 token cache"
+	// tokenCacheLockRetryDelay is how long to wait before trying to lock the cache
+	// again while another run holds it.
+	tokenCacheLockRetryDelay = 50 * time.Millisecond
+	// tokenCacheLockTimeout bounds the wait for the cache lock, after which a token
+	// is fetched without the cache, in case the run holding it hangs.
+	tokenCacheLockTimeout = 30 * time.Second
+)
+
+// tokenCache stores an access token on disk so that it can be reused by later runs
+// of the connector until it expires. Tokens are encrypted with a key derived from
+// the credentials they were issued for.
+type tokenCache struct {
+	path string
+	aead cipher.AEAD
+	// id is authenticated along with the token, so that a cache file copied over
+	// another one doesn't decrypt.
+	id []byte
+}
+
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// newTokenCache returns the cache in dir for the tokens of a client of a tenant
+// requested for an audience. secret is the credential the tokens are requested with.
+func newTokenCache(dir string, tenant string, clientId string, audience string, secret []byte) (*tokenCache, error) {
+	id := sha256.Sum256([]byte(tenant + "\x00" + clientId + "\x00" + audience))
+
+	key, err := hkdf.Key(sha256.New, secret, id[:], tokenCacheKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenCache{
+		path: filepath.Join(dir, hex.EncodeToString(id[:])+".token"),
+		aead: aead,
+		id:   id[:],
+	}, nil
+}
+
+// Fetch returns the cached token unless it is about to expire or is the rejected
+// token, and otherwise fetches a new token and caches it. The cache is locked
+// meanwhile, so that concurrent runs wait for a single token instead of each
+// requesting one. Failing to use the cache only costs a token request, but the wait
+// for the lock ends with the context.
+func (c *tokenCache) Fetch(
+	ctx context.Context,
+	rejected string,
+	fetch func(ctx context.Context) (accessToken, error),
+) (accessToken, error) {
+	l := ctxzap.Extract(ctx)
+
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return accessToken{}, ctx.Err()
+		}
+		l.Warn("baton-auth0: failed to lock the token cache", zap.Error(err))
+		return fetch(ctx)
+	}
+	defer unlock()
+
+	token, err := c.read()
+	if err != nil {
+		l.Warn("baton-auth0: failed to read the token cache", zap.Error(err))
+	}
+	if token.value != "" && token.value != rejected && time.Until(token.expiresAt) > tokenRefreshMargin {
+		return token, nil
+	}
+
+	token, err = fetch(ctx)
+	if err != nil {
+		return accessToken{}, err
+	}
+	// Tokens without an expiry can't be told apart from revoked ones later on.
+	if !token.expiresAt.IsZero() {
+		err = c.write(token)
+		if err != nil {
+			l.Warn("baton-auth0: failed to write the token cache", zap.Error(err))
+		}
+	}
+
+	return token, nil
+}
+
+// lock takes the cache lock, trying again until the lock is free, the context ends
+// or tokenCacheLockTimeout passes.
+func (c *tokenCache) lock(ctx context.Context) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(c.path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(c.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(tokenCacheLockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if locked {
+			return func() {
+				_ = unlockFile(file)
+				_ = file.Close()
+			}, nil
+		}
+		if err == nil {
+			if time.Now().After(deadline) {
+				err = errors.New("baton-auth0: timed out waiting for the token cache lock")
+			} else {
+				err = sleep(ctx, tokenCacheLockRetryDelay)
+			}
+		}
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+}
+
+func (c *tokenCache) read() (accessToken, error) {
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return accessToken{}, nil
+		}
+		return accessToken{}, err
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(content) < nonceSize {
+		return accessToken{}, errors.New("truncated token cache")
+	}
+	plaintext, err := c.aead.Open(nil, content[:nonceSize], content[nonceSize:], c.id)
+	if err != nil {
+		return accessToken{}, fmt.Errorf("undecryptable token cache: %w", err)
+	}
+
+	var cached cachedToken
+	err = json.Unmarshal(plaintext, &cached)
+	if err != nil {
+		return accessToken{}, err
+	}
+
+	return accessToken{value: cached.AccessToken, expiresAt: cached.ExpiresAt}, nil
+}
+
+// write replaces the cached token atomically, so that an interrupted write never
+// leaves a truncated cache behind.
+func (c *tokenCache) write(token accessToken) error {
+	plaintext, err := json.Marshal(cachedToken{AccessToken: token.value, ExpiresAt: token.expiresAt})
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+	content := c.aead.Seal(nonce, nonce, plaintext, c.id)
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
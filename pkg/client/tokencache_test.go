@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCacheLock(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := newTokenCache(t.TempDir(), "tenant.auth0.com", "client", "audience", []byte("secret"))
+	require.NoError(t, err)
+
+	var fetches int
+	fetch := func(context.Context) (accessToken, error) {
+		fetches++
+		return accessToken{value: "token", expiresAt: time.Now().Add(time.Hour)}, nil
+	}
+
+	// Another run holds the cache lock.
+	unlock, err := cache.lock(ctx)
+	require.NoError(t, err)
+
+	t.Run("gives up when the context ends", func(t *testing.T) {
+		waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		_, err := cache.Fetch(waitCtx, "", fetch)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Zero(t, fetches)
+	})
+
+	t.Run("waits for the lock", func(t *testing.T) {
+		time.AfterFunc(100*time.Millisecond, unlock)
+
+		token, err := cache.Fetch(ctx, "", fetch)
+		require.NoError(t, err)
+		require.Equal(t, "token", token.value)
+		require.Equal(t, 1, fetches)
+
+		// The token fetched is cached for the next run.
+		token, err = cache.Fetch(ctx, "", fetch)
+		require.NoError(t, err)
+		require.Equal(t, "token", token.value)
+		require.Equal(t, 1, fetches)
+	})
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// defaultMaxConcurrentRequests is how many requests a client has in flight at most
+// when no other maximum is configured.
+const defaultMaxConcurrentRequests = 10
+
+// concurrencyLimiter is a transport that lets at most a fixed number of requests be
+// in flight at a time. A request holds its slot until its response body is closed,
+// and holds a connection just as long, which also bounds the connections the
+// client opens.
+type concurrencyLimiter struct {
+	next  http.RoundTripper
+	slots chan struct{}
+}
+
+func newConcurrencyLimiter(next http.RoundTripper, maxConcurrentRequests int) *concurrencyLimiter {
+	if maxConcurrentRequests <= 0 {
+		maxConcurrentRequests = defaultMaxConcurrentRequests
+	}
+	return &concurrencyLimiter{
+		next:  next,
+		slots: make(chan struct{}, maxConcurrentRequests),
+	}
+}
+
+func (t *concurrencyLimiter) RoundTrip(request *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-t.slots })
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+
+	return response, nil
+}
+
+// releasingBody releases the slot of its request once it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -7,7 +7,35 @@ type Auth0 struct {
 	Auth0BaseUrl string `mapstructure:"auth0-base-url"`
 	Auth0ClientId string `mapstructure:"auth0-client-id"`
 	Auth0ClientSecret string `mapstructure:"auth0-client-secret"`
+	Auth0PrivateKey string `mapstructure:"auth0-private-key"`
+	Auth0PrivateKeyId string `mapstructure:"auth0-private-key-id"`
+	Auth0PrivateKeyAlgorithm string `mapstructure:"auth0-private-key-algorithm"`
+	Auth0ManagementApiToken string `mapstructure:"auth0-management-api-token"`
+	Auth0MtlsCertificate string `mapstructure:"auth0-mtls-certificate"`
+	Auth0MtlsKey string `mapstructure:"auth0-mtls-key"`
+	Auth0MtlsTokenUrl string `mapstructure:"auth0-mtls-token-url"`
 	SyncPermissions bool `mapstructure:"sync-permissions"`
+	UserSyncStrategy string `mapstructure:"user-sync-strategy"`
+	UserEmployeeIdPath string `mapstructure:"user-employee-id-path"`
+	UserManagerPath string `mapstructure:"user-manager-path"`
+	UserDepartmentPath string `mapstructure:"user-department-path"`
+	UserQuery string `mapstructure:"user-query"`
+	IncludeConnections []string `mapstructure:"include-connections"`
+	ExcludeConnections []string `mapstructure:"exclude-connections"`
+	IncludeOrganizations []string `mapstructure:"include-organizations"`
+	ExcludeOrganizations []string `mapstructure:"exclude-organizations"`
+	IncludeRoles []string `mapstructure:"include-roles"`
+	ExcludeRoles []string `mapstructure:"exclude-roles"`
+	OrganizationInvitations bool `mapstructure:"organization-invitations"`
+	OrganizationInvitationClientId string `mapstructure:"organization-invitation-client-id"`
+	OrganizationInvitationInviter string `mapstructure:"organization-invitation-inviter"`
+	OrganizationInvitationRoles []string `mapstructure:"organization-invitation-roles"`
+	IncrementalSync bool `mapstructure:"incremental-sync"`
+	LogStreamQueuePath string `mapstructure:"log-stream-queue-path"`
+	DisableTokenCache bool `mapstructure:"disable-token-cache"`
+	TokenCachePath string `mapstructure:"token-cache-path"`
+	MaxRequestsPerSecond int `mapstructure:"max-requests-per-second"`
+	MaxConcurrentRequests int `mapstructure:"max-concurrent-requests"`
 }
 
 func (c *Auth0) findFieldByTag(tagValue string) (any, bool) {
@@ -18,20 +18,183 @@ var (
 		field.WithDisplayName("Client ID"),
 		field.WithDescription("Auth0 Machine-to-Machine application client ID"),
 		field.WithPlaceholder("your_client_id"),
-		field.WithRequired(true),
 	)
 	ClientSecretField = field.StringField(
 		"auth0-client-secret",
 		field.WithDisplayName("Client Secret"),
 		field.WithDescription("Auth0 Machine-to-Machine application client secret"),
 		field.WithIsSecret(true),
-		field.WithRequired(true),
+	)
+	PrivateKeyField = field.StringField(
+		"auth0-private-key",
+		field.WithDisplayName("Private Key"),
+		field.WithDescription("PEM encoded RSA private key signing the client assertions of Private Key JWT authentication"),
+		field.WithIsSecret(true),
+	)
+	PrivateKeyIdField = field.StringField(
+		"auth0-private-key-id",
+		field.WithDisplayName("Private Key ID"),
+		field.WithDescription("Key ID (kid) of the application credential the private key belongs to"),
+	)
+	PrivateKeyAlgorithmField = field.StringField(
+		"auth0-private-key-algorithm",
+		field.WithDisplayName("Private Key Algorithm"),
+		field.WithDescription("Algorithm signing the client assertions: RS256 or PS256"),
+		field.WithDefaultValue("RS256"),
+		field.WithString(func(r *field.StringRuler) {
+			r.In([]string{"RS256", "PS256"})
+		}),
+	)
+	ManagementAPITokenField = field.StringField(
+		"auth0-management-api-token",
+		field.WithDisplayName("Management API Token"),
+		field.WithDescription("Pre-issued Management API token to use instead of requesting one, for break-glass access"),
+		field.WithIsSecret(true),
+	)
+	MTLSCertificateField = field.StringField(
+		"auth0-mtls-certificate",
+		field.WithDisplayName("mTLS Client Certificate"),
+		field.WithDescription("PEM encoded client certificate of mTLS client authentication"),
+	)
+	MTLSKeyField = field.StringField(
+		"auth0-mtls-key",
+		field.WithDisplayName("mTLS Client Key"),
+		field.WithDescription("PEM encoded private key of the mTLS client certificate"),
+		field.WithIsSecret(true),
+	)
+	MTLSTokenUrlField = field.StringField(
+		"auth0-mtls-token-url",
+		field.WithDisplayName("mTLS Token URL"),
+		field.WithDescription("Token endpoint of the tenant's mTLS custom domain (e.g., https://mtls.login.example.com/oauth/token)"),
 	)
 	SyncPermissions = field.BoolField(
 		"sync-permissions",
 		field.WithDisplayName("Sync Permissions"),
 		field.WithDescription("Sync permissions along with roles and users"),
 	)
+	UserSyncStrategyField = field.StringField(
+		"user-sync-strategy",
+		field.WithDisplayName("User Sync Strategy"),
+		field.WithDescription("How users are listed: search pages through the user search, export downloads a bulk users export, which is faster for large tenants"),
+		field.WithDefaultValue("search"),
+		field.WithString(func(r *field.StringRuler) {
+			r.In([]string{"search", "export"})
+		}),
+	)
+	UserEmployeeIdPathField = field.StringField(
+		"user-employee-id-path",
+		field.WithDisplayName("Employee ID Path"),
+		field.WithDescription("Dot separated path of the users' employee ID in their app_metadata (e.g., hr.employee_id)"),
+	)
+	UserManagerPathField = field.StringField(
+		"user-manager-path",
+		field.WithDisplayName("Manager Path"),
+		field.WithDescription("Dot separated path of the users' manager in their app_metadata (e.g., hr.manager_email)"),
+	)
+	UserDepartmentPathField = field.StringField(
+		"user-department-path",
+		field.WithDisplayName("Department Path"),
+		field.WithDescription("Dot separated path of the users' department in their app_metadata (e.g., hr.department)"),
+	)
+	UserQueryField = field.StringField(
+		"user-query",
+		field.WithDisplayName("User Query"),
+		field.WithDescription("User search query (Lucene syntax) that synced users must match, e.g. app_metadata.workforce:true"),
+	)
+	IncludeConnectionsField = field.StringSliceField(
+		"include-connections",
+		field.WithDisplayName("Include Connections"),
+		field.WithDescription("Names of the connections to sync, along with their users, all connections if empty"),
+	)
+	ExcludeConnectionsField = field.StringSliceField(
+		"exclude-connections",
+		field.WithDisplayName("Exclude Connections"),
+		field.WithDescription("Names of the connections not to sync, along with their users"),
+	)
+	IncludeOrganizationsField = field.StringSliceField(
+		"include-organizations",
+		field.WithDisplayName("Include Organizations"),
+		field.WithDescription("IDs or names of the organizations to sync, or metadata:<key> for those with a metadata key, all organizations if empty"),
+	)
+	ExcludeOrganizationsField = field.StringSliceField(
+		"exclude-organizations",
+		field.WithDisplayName("Exclude Organizations"),
+		field.WithDescription("IDs or names of the organizations not to sync, or metadata:<key> for those with a metadata key"),
+	)
+	IncludeRolesField = field.StringSliceField(
+		"include-roles",
+		field.WithDisplayName("Include Roles"),
+		field.WithDescription("Name patterns (e.g. Admin*) of the roles to sync, all roles if empty"),
+	)
+	ExcludeRolesField = field.StringSliceField(
+		"exclude-roles",
+		field.WithDisplayName("Exclude Roles"),
+		field.WithDescription("Name patterns (e.g. Admin*) of the roles not to sync"),
+	)
+	OrganizationInvitationsField = field.BoolField(
+		"organization-invitations",
+		field.WithDisplayName("Invite to Organizations"),
+		field.WithDescription("Grant organization membership by sending an invitation instead of adding the user directly"),
+	)
+	OrganizationInvitationClientIdField = field.StringField(
+		"organization-invitation-client-id",
+		field.WithDisplayName("Invitation Application Client ID"),
+		field.WithDescription("Client ID of the application users are sent to when accepting an organization invitation"),
+	)
+	OrganizationInvitationInviterField = field.StringField(
+		"organization-invitation-inviter",
+		field.WithDisplayName("Invitation Inviter Name"),
+		field.WithDescription("Name shown as the inviter in organization invitations, defaults to the tenant's name"),
+	)
+	OrganizationInvitationRolesField = field.StringSliceField(
+		"organization-invitation-roles",
+		field.WithDisplayName("Invitation Role IDs"),
+		field.WithDescription("IDs of the roles to assign within the organization when an invitation is accepted"),
+	)
+	IncrementalSyncField = field.BoolField(
+		"incremental-sync",
+		field.WithDisplayName("Incremental Sync"),
+		field.WithDescription("Report the users updated since the last poll of the log event feed, including updates missing from the tenant logs"),
+	)
+	MaxRequestsPerSecondField = field.IntField(
+		"max-requests-per-second",
+		field.WithDisplayName("Max Requests per Second"),
+		field.WithDescription("Maximum number of Management API requests per second, 0 for no limit besides the tenant's"),
+		field.WithDefaultValue(0),
+	)
+	MaxConcurrentRequestsField = field.IntField(
+		"max-concurrent-requests",
+		field.WithDisplayName("Max Concurrent Requests"),
+		field.WithDescription("Maximum number of Management API requests in flight at a time"),
+		field.WithDefaultValue(10),
+	)
+	DisableTokenCacheField = field.BoolField(
+		"disable-token-cache",
+		field.WithDisplayName("Disable Token Cache"),
+		field.WithDescription("Request a new access token on every run instead of reusing a cached one"),
+	)
+	TokenCachePathField = field.StringField(
+		"token-cache-path",
+		field.WithDisplayName("Token Cache Path"),
+		field.WithDescription("Directory access tokens are cached in, defaults to the user cache directory"),
+	)
+	LogStreamQueuePathField = field.StringField(
+		"log-stream-queue-path",
+		field.WithDisplayName("Log Stream Queue Path"),
+		field.WithDescription("Path of the file the log-stream-receiver command queues log stream entries in, read by the event feed"),
+	)
+	LogStreamAddressField = field.StringField(
+		"log-stream-address",
+		field.WithDisplayName("Log Stream Address"),
+		field.WithDescription("Address the log stream receiver listens on"),
+		field.WithDefaultValue(":8080"),
+	)
+	LogStreamAuthorizationField = field.StringField(
+		"log-stream-authorization",
+		field.WithDisplayName("Log Stream Authorization"),
+		field.WithDescription("Authorization header value configured on the Auth0 webhook log stream"),
+		field.WithIsSecret(true),
+	)
 )
 
 // ConfigurationFields defines the external configuration required for the connector to run.
@@ -39,12 +202,71 @@ var ConfigurationFields = []field.SchemaField{
 	BaseUrlField,
 	ClientIdField,
 	ClientSecretField,
+	PrivateKeyField,
+	PrivateKeyIdField,
+	PrivateKeyAlgorithmField,
+	ManagementAPITokenField,
+	MTLSCertificateField,
+	MTLSKeyField,
+	MTLSTokenUrlField,
 	SyncPermissions,
+	UserSyncStrategyField,
+	UserEmployeeIdPathField,
+	UserManagerPathField,
+	UserDepartmentPathField,
+	UserQueryField,
+	IncludeConnectionsField,
+	ExcludeConnectionsField,
+	IncludeOrganizationsField,
+	ExcludeOrganizationsField,
+	IncludeRolesField,
+	ExcludeRolesField,
+	OrganizationInvitationsField,
+	OrganizationInvitationClientIdField,
+	OrganizationInvitationInviterField,
+	OrganizationInvitationRolesField,
+	IncrementalSyncField,
+	LogStreamQueuePathField,
+	DisableTokenCacheField,
+	TokenCachePathField,
+	MaxRequestsPerSecondField,
+	MaxConcurrentRequestsField,
+}
+
+// FieldRelationships defines relationships between the fields listed in ConfigurationFields.
+var FieldRelationships = []field.SchemaFieldRelationship{
+	field.FieldsAtLeastOneUsed(ClientSecretField, PrivateKeyField, MTLSCertificateField, ManagementAPITokenField),
+	field.FieldsMutuallyExclusive(ClientSecretField, PrivateKeyField, MTLSCertificateField, ManagementAPITokenField),
+	field.FieldsDependentOn(
+		[]field.SchemaField{ClientSecretField, PrivateKeyField, MTLSCertificateField},
+		[]field.SchemaField{ClientIdField},
+	),
+	field.FieldsDependentOn(
+		[]field.SchemaField{PrivateKeyIdField},
+		[]field.SchemaField{PrivateKeyField},
+	),
+	field.FieldsRequiredTogether(MTLSCertificateField, MTLSKeyField),
+	field.FieldsDependentOn(
+		[]field.SchemaField{MTLSTokenUrlField},
+		[]field.SchemaField{MTLSCertificateField},
+	),
+	field.FieldsDependentOn(
+		[]field.SchemaField{OrganizationInvitationsField},
+		[]field.SchemaField{OrganizationInvitationClientIdField},
+	),
+}
+
+// LogStreamReceiverFields defines the configuration of the log-stream-receiver command.
+var LogStreamReceiverFields = []field.SchemaField{
+	LogStreamAddressField,
+	LogStreamAuthorizationField,
+	LogStreamQueuePathField,
 }
 
 // Config defines the configuration for the Auth0 connector.
 var Config = field.NewConfiguration(
 	ConfigurationFields,
+	field.WithConstraints(FieldRelationships...),
 	field.WithConnectorDisplayName("Auth0"),
 	field.WithHelpUrl("/docs/baton/auth0"),
 	field.WithIconUrl("/static/app-icons/auth0.svg"),
@@ -0,0 +1,124 @@
+package connector
+
+import (
+	"context"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+var _ connectorbuilder.ResourceSyncer = (*applicationBuilder)(nil)
+
+type applicationBuilder struct {
+	client *client2.Client
+}
+
+func newApplicationBuilder(client *client2.Client) *applicationBuilder {
+	return &applicationBuilder{client: client}
+}
+
+func (b *applicationBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return applicationResourceType
+}
+
+// Create a new connector resource for an Auth0 application (client).
+func applicationResource(application client2.Application, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	grantTypes := make([]interface{}, 0, len(application.GrantTypes))
+	for _, grantType := range application.GrantTypes {
+		grantTypes = append(grantTypes, grantType)
+	}
+
+	return resourceSdk.NewAppResource(
+		application.Name,
+		applicationResourceType,
+		application.ClientId,
+		[]resourceSdk.AppTraitOption{
+			resourceSdk.WithAppProfile(map[string]interface{}{
+				"client_id":                  application.ClientId,
+				"name":                       application.Name,
+				"app_type":                   application.AppType,
+				"is_first_party":             application.IsFirstParty,
+				"grant_types":                grantTypes,
+				"token_endpoint_auth_method": application.TokenEndpointAuthMethod,
+			}),
+		},
+		resourceSdk.WithDescription(application.Description),
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+// List returns all the applications (clients) from the tenant as resource objects.
+func (b *applicationBuilder) List(
+	ctx context.Context,
+	parentResourceID *v2.ResourceId,
+	pToken *pagination.Token,
+) (
+	[]*v2.Resource,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	outputResources := make([]*v2.Resource, 0)
+	var outputAnnotations annotations.Annotations
+
+	page, limit, _, err := client2.ParsePaginationToken(pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	applications, total, rateLimitData, err := b.client.GetClients(ctx, limit, page)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, "", outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if len(applications) == 0 {
+		return outputResources, "", outputAnnotations, nil
+	}
+
+	for _, application := range applications {
+		applicationResource0, err := applicationResource(application, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		outputResources = append(outputResources, applicationResource0)
+	}
+
+	nextToken := client2.GetNextToken(page, limit, total)
+	return outputResources, nextToken, outputAnnotations, nil
+}
+
+// Entitlements always returns an empty slice for applications.
+func (b *applicationBuilder) Entitlements(
+	_ context.Context,
+	_ *v2.Resource,
+	_ *pagination.Token,
+) (
+	[]*v2.Entitlement,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	return nil, "", nil, nil
+}
+
+// Grants always returns an empty slice for applications since they don't have any entitlements.
+func (b *applicationBuilder) Grants(
+	_ context.Context,
+	_ *v2.Resource,
+	_ *pagination.Token,
+) (
+	[]*v2.Grant,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	return nil, "", nil, nil
+}
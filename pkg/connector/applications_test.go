@@ -0,0 +1,213 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+// apiTenant serves the applications, resource servers and client grants of a
+// tenant, and the permissions assigned directly to its users.
+type apiTenant struct {
+	mu              sync.Mutex
+	applications    []client2.Application
+	resourceServers []*client2.ResourceServer
+	clientGrants    []client2.ClientGrant
+	permissions     map[string][]client2.RolePermission
+	url             string
+}
+
+// apiPage returns the page of items a paginated request asks for.
+func apiPage[T any](r *http.Request, items []T) []T {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = len(items)
+	}
+	start := min(page*perPage, len(items))
+	return items[start:min(start+perPage, len(items))]
+}
+
+func (s *apiTenant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case r.URL.Path == "/api/v2/clients":
+		_ = json.NewEncoder(w).Encode(client2.ClientsResponse{
+			PaginatedResponse: client2.PaginatedResponse{Total: len(s.applications)},
+			Clients:           apiPage(r, s.applications),
+		})
+	case r.URL.Path == "/api/v2/resource-servers":
+		_ = json.NewEncoder(w).Encode(client2.ResourceServerResponse{
+			PaginatedResponse: client2.PaginatedResponse{Total: len(s.resourceServers)},
+			ResourceServers:   apiPage(r, s.resourceServers),
+		})
+	case strings.HasPrefix(r.URL.Path, "/api/v2/resource-servers/"):
+		for _, server := range s.resourceServers {
+			if r.URL.Path == "/api/v2/resource-servers/"+server.Id {
+				_ = json.NewEncoder(w).Encode(server)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.URL.Path == "/api/v2/client-grants":
+		var found []client2.ClientGrant
+		for _, clientGrant := range s.clientGrants {
+			if audience := r.URL.Query().Get("audience"); audience != "" && clientGrant.Audience != audience {
+				continue
+			}
+			if clientId := r.URL.Query().Get("client_id"); clientId != "" && clientGrant.ClientId != clientId {
+				continue
+			}
+			found = append(found, clientGrant)
+		}
+		_ = json.NewEncoder(w).Encode(client2.ClientGrantsResponse{
+			PaginatedResponse: client2.PaginatedResponse{Total: len(found)},
+			ClientGrants:      apiPage(r, found),
+		})
+	case strings.HasPrefix(r.URL.Path, "/api/v2/users/") && strings.HasSuffix(r.URL.Path, "/permissions"):
+		userId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v2/users/"), "/permissions")
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(client2.UserPermissionsResponse{
+				PaginatedResponse: client2.PaginatedResponse{Total: len(s.permissions[userId])},
+				Permissions:       apiPage(r, s.permissions[userId]),
+			})
+			return
+		}
+
+		var body struct {
+			Permissions []client2.RolePermission `json:"permissions"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, permission := range body.Permissions {
+			s.permissions[userId] = slices.DeleteFunc(s.permissions[userId], func(assigned client2.RolePermission) bool {
+				return assigned == permission
+			})
+			if r.Method == http.MethodPost {
+				s.permissions[userId] = append(s.permissions[userId], permission)
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newAPITenant returns a tenant with the Management API and a custom API.
+func newAPITenant(t *testing.T) (*apiTenant, *client2.Client) {
+	tenant := &apiTenant{
+		applications: []client2.Application{
+			{
+				ClientId:                "client_1",
+				Name:                    "Dashboard",
+				Description:             "Internal dashboard",
+				AppType:                 "regular_web",
+				IsFirstParty:            true,
+				GrantTypes:              []string{"authorization_code", "refresh_token"},
+				TokenEndpointAuthMethod: "client_secret_post",
+			},
+			{ClientId: "client_2", Name: "Provisioning", AppType: "non_interactive"},
+			{ClientId: "client_3", Name: "Mobile", AppType: "native"},
+		},
+		resourceServers: []*client2.ResourceServer{
+			{
+				Id:         "rs_1",
+				Name:       "Auth0 Management API",
+				Identifier: "https://tenant.auth0.com/api/v2/",
+				IsSystem:   true,
+				Scopes:     []client2.ResourceServerScope{{Value: "read:users", Description: "Read Users"}, {Value: "delete:users"}},
+			},
+			{
+				Id:         "rs_2",
+				Name:       "Orders API",
+				Identifier: "https://orders.example.com",
+				Scopes:     []client2.ResourceServerScope{{Value: "read:orders"}},
+			},
+		},
+		clientGrants: []client2.ClientGrant{
+			{Id: "cgr_1", ClientId: "client_2", Audience: "https://tenant.auth0.com/api/v2/", Scope: []string{"read:users", "delete:users"}},
+			{Id: "cgr_2", ClientId: "client_1", Audience: "https://tenant.auth0.com/api/v2/", Scope: []string{"read:users"}},
+			{Id: "cgr_3", ClientId: "client_1", Audience: "https://orders.example.com", Scope: []string{}},
+		},
+		permissions: map[string][]client2.RolePermission{},
+	}
+	server := httptest.NewServer(tenant)
+	t.Cleanup(server.Close)
+	tenant.url = server.URL
+
+	c0, err := client2.New(context.Background(), server.URL, "mock", "token")
+	require.NoError(t, err)
+	return tenant, c0
+}
+
+func TestApplicationBuilder(t *testing.T) {
+	ctx := context.Background()
+	_, c0 := newAPITenant(t)
+	ab := newApplicationBuilder(c0)
+
+	require.Equal(t, []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP}, ab.ResourceType(ctx).Traits)
+
+	var applications []*v2.Resource
+	token := &pagination.Token{Size: 2}
+	for {
+		resources, nextToken, _, err := ab.List(ctx, nil, token)
+		require.NoError(t, err)
+		applications = append(applications, resources...)
+		if nextToken == "" {
+			break
+		}
+		token = &pagination.Token{Size: 2, Token: nextToken}
+	}
+	require.Len(t, applications, 3)
+
+	dashboard := applications[0]
+	require.Equal(t, &v2.ResourceId{ResourceType: applicationResourceType.Id, Resource: "client_1"}, dashboard.Id)
+	require.Equal(t, "Dashboard", dashboard.DisplayName)
+	require.Equal(t, "Internal dashboard", dashboard.Description)
+
+	appTrait, err := resource.GetAppTrait(dashboard)
+	require.NoError(t, err)
+	profile := appTrait.GetProfile().GetFields()
+	require.Equal(t, "regular_web", profile["app_type"].GetStringValue())
+	require.True(t, profile["is_first_party"].GetBoolValue())
+	require.Equal(t, "client_secret_post", profile["token_endpoint_auth_method"].GetStringValue())
+	grantTypes := make([]string, 0)
+	for _, grantType := range profile["grant_types"].GetListValue().GetValues() {
+		grantTypes = append(grantTypes, grantType.GetStringValue())
+	}
+	require.Equal(t, []string{"authorization_code", "refresh_token"}, grantTypes)
+
+	// Applications are granted scopes rather than having entitlements of their own.
+	entitlements, _, _, err := ab.Entitlements(ctx, dashboard, nil)
+	require.NoError(t, err)
+	require.Empty(t, entitlements)
+	grants, _, _, err := ab.Grants(ctx, dashboard, nil)
+	require.NoError(t, err)
+	require.Empty(t, grants)
+
+	// The client grants of scopes name the applications as listed.
+	scopes, _, _, err := newScopeBuilder(c0).List(ctx, nil, nil)
+	require.NoError(t, err)
+	scopeGrants, _, _, err := newScopeBuilder(c0).Grants(ctx, scopes[0], nil)
+	require.NoError(t, err)
+	require.Len(t, scopeGrants, 2)
+	require.Equal(t, applications[1].Id, scopeGrants[0].Principal.Id)
+	require.Equal(t, dashboard.Id, scopeGrants[1].Principal.Id)
+}
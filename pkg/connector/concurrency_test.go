@@ -0,0 +1,235 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	concurrentWorkers     = 4
+	maxConcurrentRequests = 3
+	// Pages are bounded so that a builder that never stops paginating fails the test
+	// instead of hanging it.
+	maxPages = 10
+)
+
+// fakeTenant serves a tenant with one of each resource and records how many
+// requests it has in flight at most.
+type fakeTenant struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	requests    atomic.Int32
+}
+
+func (f *fakeTenant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	inFlight := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		current := f.maxInFlight.Load()
+		if inFlight <= current || f.maxInFlight.CompareAndSwap(current, inFlight) {
+			break
+		}
+	}
+	f.requests.Add(1)
+	// Keep requests in flight long enough to overlap.
+	time.Sleep(time.Millisecond)
+
+	w.Header().Set("Content-Type", "application/json")
+	response := fakeTenantResponse(r.URL.Path)
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"statusCode":404,"error":"Not Found"}`))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func fakeTenantResponse(path string) any {
+	if path == "/oauth/token" {
+		return client2.AuthResponse{AccessToken: "mock-token", TokenType: "Bearer", ExpiresIn: 86400}
+	}
+
+	page := client2.PaginatedResponse{Start: 0, Limit: 100, Total: 1}
+	user := client2.User{UserId: "auth0|1", Email: "user@example.com", Name: "User"}
+	role := client2.Role{ID: "rol_1", Name: "Role"}
+	permission := client2.RolePermission{
+		PermissionName:           "read:things",
+		ResourceServerIdentifier: "https://api.example.com",
+	}
+	resourceServer := &client2.ResourceServer{
+		Id:         "rs_1",
+		Name:       "API",
+		Identifier: "https://api.example.com",
+		Scopes:     []client2.ResourceServerScope{{Value: "read:things"}},
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/v2/"), "/"), "/")
+	switch {
+	case len(segments) == 1 && segments[0] == "users":
+		return client2.UsersResponse{PaginatedResponse: page, Length: 1, Users: []client2.User{user}}
+	case len(segments) == 3 && segments[0] == "users" && segments[2] == "roles":
+		return client2.RolesResponse{PaginatedResponse: page, Roles: []client2.Role{role}}
+	case len(segments) == 3 && segments[0] == "users" && segments[2] == "permissions":
+		return client2.UserPermissionsResponse{PaginatedResponse: page, Permissions: []client2.RolePermission{permission}}
+	case len(segments) == 1 && segments[0] == "roles":
+		return client2.RolesResponse{PaginatedResponse: page, Roles: []client2.Role{role}}
+	case len(segments) == 3 && segments[0] == "roles" && segments[2] == "users":
+		return client2.RolesUsersCheckpointResponse{Users: []client2.User{user}}
+	case len(segments) == 3 && segments[0] == "roles" && segments[2] == "permissions":
+		return []client2.RolePermission{permission}
+	case len(segments) == 1 && segments[0] == "organizations":
+		return client2.OrganizationsResponse{
+			PaginatedResponse: page,
+			Organizations:     []client2.Organization{{ID: "org_1", Name: "org"}},
+		}
+	case len(segments) == 3 && segments[0] == "organizations" && segments[2] == "members":
+		return client2.OrganizationMembersResponse{
+			PaginatedResponse: page,
+			Members:           []client2.OrganizationMember{{User: user, Roles: []client2.Role{role}}},
+		}
+	case len(segments) == 3 && segments[0] == "organizations" && segments[2] == "invitations":
+		return []client2.OrganizationInvitation{{Id: "uinv_1", OrganizationId: "org_1"}}
+	case len(segments) == 3 && segments[0] == "organizations" && segments[2] == "enabled_connections":
+		return client2.OrganizationConnectionsResponse{
+			PaginatedResponse:  page,
+			EnabledConnections: []client2.OrganizationConnection{{ConnectionId: "con_1"}},
+		}
+	case len(segments) == 1 && segments[0] == "clients":
+		return client2.ClientsResponse{PaginatedResponse: page, Clients: []client2.Application{{ClientId: "cli_1", Name: "App"}}}
+	case len(segments) == 1 && segments[0] == "connections":
+		return client2.ConnectionsResponse{
+			PaginatedResponse: page,
+			Connections:       []client2.Connection{{Id: "con_1", Name: "db", EnabledClients: []string{"cli_1"}}},
+		}
+	case len(segments) == 1 && segments[0] == "resource-servers":
+		return client2.ResourceServerResponse{PaginatedResponse: page, ResourceServers: []*client2.ResourceServer{resourceServer}}
+	case len(segments) == 2 && segments[0] == "resource-servers":
+		return resourceServer
+	case len(segments) == 1 && segments[0] == "client-grants":
+		return client2.ClientGrantsResponse{
+			PaginatedResponse: page,
+			ClientGrants: []client2.ClientGrant{{
+				Id:       "cgr_1",
+				ClientId: "cli_1",
+				Audience: "https://api.example.com",
+				Scope:    []string{"read:things"},
+			}},
+		}
+	}
+
+	return nil
+}
+
+// syncAll lists every resource of a builder along with its entitlements and
+// grants, like a sync does. It returns the first error instead of failing the test,
+// since it runs outside of the test goroutine.
+func syncAll(ctx context.Context, syncer connectorbuilder.ResourceSyncer, parentResourceID *v2.ResourceId) error {
+	resourceType := syncer.ResourceType(ctx).Id
+
+	var resources []*v2.Resource
+	pToken := &pagination.Token{Size: 50}
+	for range maxPages {
+		page, nextToken, _, err := syncer.List(ctx, parentResourceID, pToken)
+		if err != nil {
+			return fmt.Errorf("listing %s: %w", resourceType, err)
+		}
+		resources = append(resources, page...)
+		if nextToken == "" {
+			break
+		}
+		pToken = &pagination.Token{Size: 50, Token: nextToken}
+	}
+
+	for _, resource := range resources {
+		pToken := &pagination.Token{Size: 50}
+		for range maxPages {
+			_, nextToken, _, err := syncer.Entitlements(ctx, resource, pToken)
+			if err != nil {
+				return fmt.Errorf("listing entitlements of %s %s: %w", resourceType, resource.Id.Resource, err)
+			}
+			if nextToken == "" {
+				break
+			}
+			pToken = &pagination.Token{Size: 50, Token: nextToken}
+		}
+
+		pToken = &pagination.Token{Size: 50}
+		for range maxPages {
+			_, nextToken, _, err := syncer.Grants(ctx, resource, pToken)
+			if err != nil {
+				return fmt.Errorf("listing grants of %s %s: %w", resourceType, resource.Id.Resource, err)
+			}
+			if nextToken == "" {
+				break
+			}
+			pToken = &pagination.Token{Size: 50, Token: nextToken}
+		}
+	}
+
+	return nil
+}
+
+func TestBuildersConcurrently(t *testing.T) {
+	ctx := context.Background()
+
+	tenant := &fakeTenant{}
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+
+	c0, err := client2.New(
+		ctx,
+		server.URL,
+		"mock",
+		"token",
+		client2.WithMaxConcurrentRequests(maxConcurrentRequests),
+	)
+	require.NoError(t, err)
+
+	connector := &Connector{client: c0, syncPermissions: true, filter: &resourceFilter{}}
+	organizationId := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "org_1"}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, syncer := range connector.ResourceSyncers(ctx) {
+		var parentResourceID *v2.ResourceId
+		if syncer.ResourceType(ctx).Id == invitationResourceType.Id {
+			parentResourceID = organizationId
+		}
+
+		for range concurrentWorkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := syncAll(ctx, syncer, parentResourceID)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	require.Empty(t, errs)
+
+	require.Greater(t, tenant.requests.Load(), int32(1))
+	require.LessOrEqual(t, tenant.maxInFlight.Load(), int32(maxConcurrentRequests))
+}
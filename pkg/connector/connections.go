@@ -0,0 +1,331 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+var (
+	_ connectorbuilder.ResourceSyncer      = (*connectionBuilder)(nil)
+	_ connectorbuilder.ResourceProvisioner = (*connectionBuilder)(nil)
+)
+
+const (
+	connectionUserEntitlementName    = "user"
+	connectionEnabledEntitlementName = "enabled"
+)
+
+type connectionBuilder struct {
+	client *client2.Client
+	filter *resourceFilter
+}
+
+func newConnectionBuilder(client *client2.Client, filter *resourceFilter) *connectionBuilder {
+	return &connectionBuilder{client: client, filter: filter}
+}
+
+func (b *connectionBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return connectionResourceType
+}
+
+// Create a new connector resource for an Auth0 connection. Connections are keyed by
+// name, which is unique and immutable within a tenant, because that is how user
+// identities refer to them.
+func connectionResource(connection client2.Connection, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	enabledClients := make([]interface{}, 0, len(connection.EnabledClients))
+	for _, clientId := range connection.EnabledClients {
+		enabledClients = append(enabledClients, clientId)
+	}
+	realms := make([]interface{}, 0, len(connection.Realms))
+	for _, realm := range connection.Realms {
+		realms = append(realms, realm)
+	}
+
+	displayName := connection.DisplayName
+	if displayName == "" {
+		displayName = connection.Name
+	}
+
+	return resourceSdk.NewResource(
+		displayName,
+		connectionResourceType,
+		connection.Name,
+		resourceSdk.WithResourceProfile(map[string]interface{}{
+			"id":                   connection.Id,
+			"name":                 connection.Name,
+			"strategy":             connection.Strategy,
+			"enabled_clients":      enabledClients,
+			"realms":               realms,
+			"is_domain_connection": connection.IsDomainConnection,
+		}),
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+// List returns all the connections from the tenant as resource objects.
+func (b *connectionBuilder) List(
+	ctx context.Context,
+	parentResourceID *v2.ResourceId,
+	pToken *pagination.Token,
+) (
+	[]*v2.Resource,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	outputResources := make([]*v2.Resource, 0)
+	var outputAnnotations annotations.Annotations
+
+	page, limit, _, err := client2.ParsePaginationToken(pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	connections, total, rateLimitData, err := b.client.GetConnections(ctx, limit, page)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, "", outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if len(connections) == 0 {
+		return outputResources, "", outputAnnotations, nil
+	}
+
+	for _, connection := range connections {
+		if !b.filter.allowsConnection(connection.Name) {
+			continue
+		}
+		connectionResource0, err := connectionResource(connection, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		outputResources = append(outputResources, connectionResource0)
+	}
+
+	nextToken := client2.GetNextToken(page, limit, total)
+	return outputResources, nextToken, outputAnnotations, nil
+}
+
+func (b *connectionBuilder) Entitlements(
+	_ context.Context,
+	resource *v2.Resource,
+	_ *pagination.Token,
+) (
+	[]*v2.Entitlement,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	return []*v2.Entitlement{
+		sdkEntitlement.NewAssignmentEntitlement(
+			resource,
+			connectionUserEntitlementName,
+			sdkEntitlement.WithGrantableTo(userResourceType),
+			sdkEntitlement.WithDisplayName(
+				fmt.Sprintf("%s %s", resource.DisplayName, connectionUserEntitlementName),
+			),
+			sdkEntitlement.WithDescription(
+				fmt.Sprintf("Authenticates through the %s connection in Auth0", resource.DisplayName),
+			),
+			sdkEntitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+		),
+		sdkEntitlement.NewPermissionEntitlement(
+			resource,
+			connectionEnabledEntitlementName,
+			sdkEntitlement.WithGrantableTo(applicationResourceType),
+			sdkEntitlement.WithDisplayName(
+				fmt.Sprintf("%s %s", resource.DisplayName, connectionEnabledEntitlementName),
+			),
+			sdkEntitlement.WithDescription(
+				fmt.Sprintf("Application can log users in through the %s connection in Auth0", resource.DisplayName),
+			),
+		),
+	}, "", nil, nil
+}
+
+// Grants returns the applications enabled on the connection. Auth0 only exposes
+// which connections a user authenticates through on the user itself, so the user
+// builder emits the connection user grants from each user's identities.
+func (b *connectionBuilder) Grants(
+	ctx context.Context,
+	resource *v2.Resource,
+	_ *pagination.Token,
+) (
+	[]*v2.Grant,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+	var enabledClients []string
+
+	profile := resourceSdk.GetProfile(resource)
+	if value, ok := profile.GetFields()["enabled_clients"]; ok {
+		for _, clientId := range value.GetListValue().GetValues() {
+			enabledClients = append(enabledClients, clientId.GetStringValue())
+		}
+	} else {
+		connection, rateLimitData, err := b.client.GetConnectionByName(ctx, resource.Id.Resource)
+		if err != nil {
+			if rateLimitData != nil {
+				outputAnnotations.WithRateLimiting(rateLimitData)
+			}
+			return nil, "", outputAnnotations, wrapError(err)
+		}
+		outputAnnotations.WithRateLimiting(rateLimitData)
+		enabledClients = connection.EnabledClients
+	}
+
+	grants := make([]*v2.Grant, 0, len(enabledClients))
+	for _, clientId := range enabledClients {
+		if clientId == "" {
+			continue
+		}
+		grants = append(grants, sdkGrant.NewGrant(
+			resource,
+			connectionEnabledEntitlementName,
+			&v2.ResourceId{
+				ResourceType: applicationResourceType.Id,
+				Resource:     clientId,
+			},
+		))
+	}
+
+	return grants, "", outputAnnotations, nil
+}
+
+func (b *connectionBuilder) Grant(
+	ctx context.Context,
+	principal *v2.Resource,
+	entitlement *v2.Entitlement,
+) (
+	annotations.Annotations,
+	error,
+) {
+	l := ctxzap.Extract(ctx)
+	if principal.Id.ResourceType != applicationResourceType.Id {
+		l.Warn(
+			"baton-auth0: only applications can be enabled on a connection",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("baton-auth0: only applications can be enabled on a connection")
+	}
+
+	return b.setClientEnabled(ctx, entitlement.Resource, principal.Id.Resource, true)
+}
+
+func (b *connectionBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	principal := grant.Principal
+	if principal.Id.ResourceType != applicationResourceType.Id {
+		l.Warn(
+			"baton-auth0: only applications can be disabled on a connection",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("baton-auth0: only applications can be disabled on a connection")
+	}
+
+	return b.setClientEnabled(ctx, grant.Entitlement.Resource, principal.Id.Resource, false)
+}
+
+func (b *connectionBuilder) setClientEnabled(
+	ctx context.Context,
+	connection *v2.Resource,
+	clientId string,
+	enabled bool,
+) (
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+
+	connectionId, rateLimitData, err := getConnectionId(ctx, b.client, connection)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, fmt.Errorf("baton-auth0: failed to get connection: %w", err)
+	}
+
+	changed, rateLimitData, err := b.client.SetConnectionClientEnabled(ctx, connectionId, clientId, enabled)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to update connection enabled clients: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if !changed {
+		if enabled {
+			outputAnnotations.Append(&v2.GrantAlreadyExists{})
+		} else {
+			outputAnnotations.Append(&v2.GrantAlreadyRevoked{})
+		}
+	}
+
+	return outputAnnotations, nil
+}
+
+// getConnectionId returns the Auth0 ID of a connection resource, from its profile
+// when present and otherwise by looking the connection up by name.
+func getConnectionId(
+	ctx context.Context,
+	client *client2.Client,
+	connection *v2.Resource,
+) (string, *v2.RateLimitDescription, error) {
+	profile := resourceSdk.GetProfile(connection)
+	if id, ok := resourceSdk.GetProfileStringValue(profile, "id"); ok && id != "" {
+		return id, nil, nil
+	}
+
+	connection0, rateLimitData, err := client.GetConnectionByName(ctx, connection.Id.Resource)
+	if err != nil {
+		return "", rateLimitData, err
+	}
+
+	return connection0.Id, rateLimitData, nil
+}
+
+// connectionUserGrants returns a grant on the connection user entitlement for every
+// connection listed in a user resource's profile that passes the filter.
+func connectionUserGrants(user *v2.Resource, filter *resourceFilter) []*v2.Grant {
+	profile := resourceSdk.GetProfile(user)
+	connections := profile.GetFields()["connections"].GetListValue().GetValues()
+
+	grants := make([]*v2.Grant, 0, len(connections))
+	for _, connection := range connections {
+		name := connection.GetStringValue()
+		if name == "" || !filter.allowsConnection(name) {
+			continue
+		}
+		grants = append(grants, sdkGrant.NewGrant(
+			&v2.Resource{
+				Id: &v2.ResourceId{
+					ResourceType: connectionResourceType.Id,
+					Resource:     name,
+				},
+			},
+			connectionUserEntitlementName,
+			user.Id,
+		))
+	}
+
+	return grants
+}
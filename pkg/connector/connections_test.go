@@ -0,0 +1,90 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/require"
+)
+
+// connectionClientsServer serves a connection whose enabled clients are changed
+// one at a time, like Auth0 does.
+type connectionClientsServer struct {
+	mu             sync.Mutex
+	enabledClients []string
+	updates        [][]client2.ConnectionClientStatus
+}
+
+func (s *connectionClientsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/connections/con_1":
+		_ = json.NewEncoder(w).Encode(client2.Connection{Id: "con_1", Name: "db", EnabledClients: s.enabledClients})
+	case r.Method == http.MethodPatch && r.URL.Path == "/api/v2/connections/con_1/clients":
+		var update []client2.ConnectionClientStatus
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		s.updates = append(s.updates, update)
+		for _, client := range update {
+			s.enabledClients = slices.DeleteFunc(s.enabledClients, func(id string) bool { return id == client.ClientId })
+			if client.Status {
+				s.enabledClients = append(s.enabledClients, client.ClientId)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestConnectionGrantEnablesOneClient(t *testing.T) {
+	ctx := context.Background()
+
+	tenant := &connectionClientsServer{enabledClients: []string{"cli_other"}}
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+
+	c0, err := client2.New(ctx, server.URL, "mock", "token")
+	require.NoError(t, err)
+	cb := newConnectionBuilder(c0, &resourceFilter{})
+
+	connection, err := connectionResource(client2.Connection{Id: "con_1", Name: "db"}, nil)
+	require.NoError(t, err)
+	entitlement := &v2.Entitlement{Resource: connection}
+	application := &v2.Resource{Id: &v2.ResourceId{ResourceType: applicationResourceType.Id, Resource: "cli_1"}}
+
+	var outputAnnotations annotations.Annotations
+	outputAnnotations, err = cb.Grant(ctx, application, entitlement)
+	require.NoError(t, err)
+	require.False(t, outputAnnotations.Contains(&v2.GrantAlreadyExists{}))
+
+	// Another application enabled concurrently is kept.
+	tenant.mu.Lock()
+	tenant.enabledClients = append(tenant.enabledClients, "cli_concurrent")
+	tenant.mu.Unlock()
+
+	outputAnnotations, err = cb.Grant(ctx, application, entitlement)
+	require.NoError(t, err)
+	require.True(t, outputAnnotations.Contains(&v2.GrantAlreadyExists{}))
+
+	_, err = cb.Revoke(ctx, &v2.Grant{Entitlement: entitlement, Principal: application})
+	require.NoError(t, err)
+
+	require.Equal(t, [][]client2.ConnectionClientStatus{
+		{{ClientId: "cli_1", Status: true}},
+		{{ClientId: "cli_1", Status: false}},
+	}, tenant.updates)
+	require.Equal(t, []string{"cli_other", "cli_concurrent"}, tenant.enabledClients)
+}
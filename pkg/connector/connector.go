@@ -2,25 +2,43 @@ package connector
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/conductorone/baton-auth0/pkg/client"
+	cfg "github.com/conductorone/baton-auth0/pkg/config"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 type Connector struct {
-	client          *client.Client
-	syncPermissions bool
+	client                  *client.Client
+	syncPermissions         bool
+	exportUsers             bool
+	organizationInvitations *organizationInvitationOptions
+	logStreamQueue          *LogStreamQueue
+	filter                  *resourceFilter
+	userMapping             userAttributeMapping
+	// incrementalSync makes the log event feed report the users updated since its
+	// last poll, including the updates the tenant logs miss.
+	incrementalSync bool
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(_ context.Context) []connectorbuilder.ResourceSyncer {
 	resourcesSyncers := []connectorbuilder.ResourceSyncer{
-		newUserBuilder(d.client),
-		newOrganizationBuilder(d.client),
-		newRoleBuilder(d.client, d.syncPermissions),
+		newUserBuilder(d.client, d.syncPermissions, d.exportUsers, d.filter, d.userMapping),
+		newOrganizationBuilder(d.client, d.organizationInvitations, d.filter),
+		newInvitationBuilder(d.client),
+		newRoleBuilder(d.client, d.syncPermissions, d.filter),
+		newApplicationBuilder(d.client),
+		newConnectionBuilder(d.client, d.filter),
 	}
 
 	if d.syncPermissions {
@@ -45,6 +63,52 @@ func (d *Connector) Metadata(_ context.Context) (*v2.ConnectorMetadata, error) {
 	return &v2.ConnectorMetadata{
 		DisplayName: "Auth0 Connector",
 		Description: "Connector for syncing identity and access data from Auth0",
+		AccountCreationSchema: &v2.ConnectorAccountCreationSchema{
+			FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
+				"email": {
+					DisplayName: "Email",
+					Required:    true,
+					Description: "The email address of the user.",
+					Placeholder: "user@example.com",
+					Order:       1,
+					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+					},
+				},
+				"name": {
+					DisplayName: "Name",
+					Required:    false,
+					Description: "The full name of the user.",
+					Placeholder: "Jane Doe",
+					Order:       2,
+					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+					},
+				},
+				"username": {
+					DisplayName: "Username",
+					Required:    false,
+					Description: "The username of the user. Only needed if the connection requires usernames.",
+					Placeholder: "jdoe",
+					Order:       3,
+					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+					},
+				},
+				"connection": {
+					DisplayName: "Connection",
+					Required:    false,
+					Description: "The name of the database connection to create the user in.",
+					Placeholder: defaultAccountConnection,
+					Order:       4,
+					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+						StringField: &v2.ConnectorAccountCreationSchema_StringField{
+							DefaultValue: proto.String(defaultAccountConnection),
+						},
+					},
+				},
+			},
+		},
 	}, nil
 }
 
@@ -55,14 +119,107 @@ func (d *Connector) Validate(_ context.Context) (annotations.Annotations, error)
 }
 
 // New returns a new instance of the connector.
-func New(ctx context.Context, baseUrl string, clientId string, clientSecret string, syncPermissions bool) (*Connector, error) {
-	client0, err := client.New(ctx, baseUrl, clientId, clientSecret)
+func New(ctx context.Context, config *cfg.Auth0) (*Connector, error) {
+	filter, err := newResourceFilter(config)
 	if err != nil {
 		return nil, err
 	}
+	exportUsers := config.UserSyncStrategy == userSyncStrategyExport
+	if exportUsers && filter.userQuery != "" {
+		return nil, fmt.Errorf("baton-auth0: a user query can't be applied to users exports, use the search user sync strategy")
+	}
+
+	clientOpts, err := authenticationOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, client.WithUserQuery(filter.usersQuery()))
+	clientOpts = append(clientOpts, client.WithMaxConcurrentRequests(config.MaxConcurrentRequests))
+	if config.MaxRequestsPerSecond > 0 {
+		clientOpts = append(clientOpts, client.WithRequestsPerSecond(float64(config.MaxRequestsPerSecond)))
+	}
+	if !config.DisableTokenCache {
+		tokenCacheDir, err := tokenCacheDir(config.TokenCachePath)
+		if err != nil {
+			ctxzap.Extract(ctx).Warn("baton-auth0: token cache disabled", zap.Error(err))
+		} else {
+			clientOpts = append(clientOpts, client.WithTokenCache(tokenCacheDir))
+		}
+	}
+
+	client0, err := client.New(ctx, config.Auth0BaseUrl, config.Auth0ClientId, config.Auth0ClientSecret, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitations *organizationInvitationOptions
+	if config.OrganizationInvitations {
+		if config.OrganizationInvitationClientId == "" {
+			return nil, fmt.Errorf("baton-auth0: organization invitations require an invitation application client ID")
+		}
+		invitations = &organizationInvitationOptions{
+			clientId:    config.OrganizationInvitationClientId,
+			inviterName: config.OrganizationInvitationInviter,
+			roles:       config.OrganizationInvitationRoles,
+		}
+	}
+
+	var logStreamQueue *LogStreamQueue
+	if config.LogStreamQueuePath != "" {
+		logStreamQueue = NewLogStreamQueue(config.LogStreamQueuePath)
+	}
 
 	return &Connector{
-		client:          client0,
-		syncPermissions: syncPermissions,
+		client:                  client0,
+		syncPermissions:         config.SyncPermissions,
+		exportUsers:             exportUsers,
+		organizationInvitations: invitations,
+		logStreamQueue:          logStreamQueue,
+		filter:                  filter,
+		userMapping: userAttributeMapping{
+			employeeIdPath: config.UserEmployeeIdPath,
+			managerPath:    config.UserManagerPath,
+			departmentPath: config.UserDepartmentPath,
+		},
+		incrementalSync: config.IncrementalSync,
 	}, nil
 }
+
+// authenticationOptions returns the client options for the configured authentication
+// method. The client secret is used when no other method is configured.
+func authenticationOptions(config *cfg.Auth0) ([]client.ClientOpt, error) {
+	switch {
+	case config.Auth0ManagementApiToken != "":
+		return []client.ClientOpt{client.WithManagementAPIToken(config.Auth0ManagementApiToken)}, nil
+
+	case config.Auth0PrivateKey != "":
+		opt, err := client.WithPrivateKeyJWT(config.Auth0PrivateKey, config.Auth0PrivateKeyId, config.Auth0PrivateKeyAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		return []client.ClientOpt{opt}, nil
+
+	case config.Auth0MtlsCertificate != "":
+		opt, err := client.WithMTLS(config.Auth0MtlsCertificate, config.Auth0MtlsKey, config.Auth0MtlsTokenUrl)
+		if err != nil {
+			return nil, err
+		}
+		return []client.ClientOpt{opt}, nil
+	}
+
+	return nil, nil
+}
+
+// tokenCacheDir returns the directory access tokens are cached in: the configured
+// path, or a directory in the user cache directory.
+func tokenCacheDir(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "baton-auth0"), nil
+}
@@ -0,0 +1,31 @@
+package connector
+
+import (
+	"errors"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// wrapError translates an error caused by an error response of the Management API
+// to the gRPC status of the response, so that baton can tell a missing resource
+// from a missing permission or a conflict. Other errors are returned as they are.
+func wrapError(err error) error {
+	var apiError *client2.APIError
+	if !errors.As(err, &apiError) {
+		return err
+	}
+
+	return status.Error(apiErrorCode(apiError), err.Error())
+}
+
+func apiErrorCode(apiError *client2.APIError) codes.Code {
+	// Auth0 answers some requests made without the needed scope with a 401.
+	if apiError.ErrorCode == "insufficient_scope" {
+		return codes.PermissionDenied
+	}
+
+	return uhttp.GrpcCodeFromHTTPStatus(apiError.StatusCode)
+}
@@ -0,0 +1,82 @@
+package connector
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWrapError(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiError client2.APIError
+		code     codes.Code
+	}{
+		{
+			name:     "insufficient scope as 403",
+			apiError: client2.APIError{StatusCode: 403, Err: "Forbidden", ErrorCode: "insufficient_scope"},
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "insufficient scope as 401",
+			apiError: client2.APIError{StatusCode: 401, Err: "Unauthorized", ErrorCode: "insufficient_scope"},
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "invalid token",
+			apiError: client2.APIError{StatusCode: 401, Err: "Unauthorized", ErrorCode: "invalid_token"},
+			code:     codes.Unauthenticated,
+		},
+		{
+			name:     "inexistent user",
+			apiError: client2.APIError{StatusCode: 404, Err: "Not Found", ErrorCode: "inexistent_user"},
+			code:     codes.NotFound,
+		},
+		{
+			name:     "bad request",
+			apiError: client2.APIError{StatusCode: 400, Err: "Bad Request", ErrorCode: "invalid_body"},
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "conflict",
+			apiError: client2.APIError{StatusCode: 409, Err: "Conflict"},
+			code:     codes.AlreadyExists,
+		},
+		{
+			name:     "rate limited",
+			apiError: client2.APIError{StatusCode: 429, Err: "Too Many Requests"},
+			code:     codes.Unavailable,
+		},
+		{
+			name:     "server error",
+			apiError: client2.APIError{StatusCode: 500, Err: "Internal Server Error"},
+			code:     codes.Unavailable,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiError := test.apiError
+			require.Equal(t, test.code, apiErrorCode(&apiError))
+
+			err := wrapError(&apiError)
+			require.Equal(t, test.code, status.Code(err))
+			require.Equal(t, apiError.Error(), status.Convert(err).Message())
+
+			// API errors wrapped with context keep their code and message.
+			err = wrapError(fmt.Errorf("baton-auth0: failed to get user: %w", &apiError))
+			require.Equal(t, test.code, status.Code(err))
+			require.Equal(t, "baton-auth0: failed to get user: "+apiError.Error(), status.Convert(err).Message())
+		})
+	}
+
+	t.Run("other errors", func(t *testing.T) {
+		err := errors.New("baton-auth0: invalid cursor")
+		require.Same(t, err, wrapError(err))
+		require.NoError(t, wrapError(nil))
+	})
+}
@@ -0,0 +1,470 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	logEventFeedId = "auth0_logs"
+	// The Management API returns at most 100 log entries per request.
+	logPageSizeMax = 100
+
+	// See https://auth0.com/docs/deploy-monitor/logs/log-event-type-codes.
+	logTypeSuccessLogin        = "s"
+	logTypeSuccessSignup       = "ss"
+	logTypeSuccessUserDeletion = "sdu"
+	logTypeSuccessAPIOperation = "sapi"
+)
+
+var (
+	_ connectorbuilder.EventProviderV2 = (*Connector)(nil)
+	_ connectorbuilder.EventFeed       = (*logEventFeed)(nil)
+)
+
+// EventFeeds returns the feeds of events built from the tenant logs, including the
+// log stream entries queued by the receiver when a queue is configured.
+func (d *Connector) EventFeeds(_ context.Context) []connectorbuilder.EventFeed {
+	feeds := []connectorbuilder.EventFeed{
+		newLogEventFeed(d.client, d.filter, d.incrementalSync),
+	}
+	if d.logStreamQueue != nil {
+		feeds = append(feeds, newLogStreamEventFeed(d.logStreamQueue))
+	}
+	return feeds
+}
+
+type logEventFeed struct {
+	client *client2.Client
+	filter *resourceFilter
+	// updatedUsers makes the feed search for the users updated since its last
+	// poll, since not every user update is logged.
+	updatedUsers bool
+}
+
+func newLogEventFeed(client *client2.Client, filter *resourceFilter, updatedUsers bool) *logEventFeed {
+	return &logEventFeed{client: client, filter: filter, updatedUsers: updatedUsers}
+}
+
+// logEventCursor is the position of the log event feed.
+type logEventCursor struct {
+	// LogId is the last log entry read.
+	LogId string `json:"log_id,omitempty"`
+	// Users is the users pagination token of the listing of updated users, which
+	// holds the updated_at high-water mark users are listed from.
+	Users string `json:"users,omitempty"`
+}
+
+// parseLogEventCursor decodes the cursor of the log event feed. Cursors used to be
+// the bare ID of the last log entry read, which are still accepted.
+func parseLogEventCursor(cursor string) (logEventCursor, error) {
+	var position logEventCursor
+	if cursor == "" {
+		return position, nil
+	}
+	if !strings.HasPrefix(cursor, "{") {
+		position.LogId = cursor
+		return position, nil
+	}
+
+	err := json.Unmarshal([]byte(cursor), &position)
+	if err != nil {
+		return position, fmt.Errorf("baton-auth0: invalid log event cursor: %w", err)
+	}
+	return position, nil
+}
+
+func (f *logEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id: logEventFeedId,
+		SupportedEventTypes: []v2.EventType{
+			v2.EventType_EVENT_TYPE_USAGE,
+			v2.EventType_EVENT_TYPE_RESOURCE_CHANGE,
+			v2.EventType_EVENT_TYPE_CREATE_GRANT,
+			v2.EventType_EVENT_TYPE_CREATE_REVOKE,
+		},
+	}
+}
+
+// ListEvents pages through the tenant logs, from the log entry the cursor names,
+// which the logs endpoint takes as its from checkpoint. Once the logs are caught
+// up, and if enabled, the users updated since the previous poll are reported as
+// changed, which lets targeted syncs pick up the updates the logs miss, like those
+// made by actions or rules.
+func (f *logEventFeed) ListEvents(
+	ctx context.Context,
+	earliestEvent *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+) (
+	[]*v2.Event,
+	*pagination.StreamState,
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+
+	take := logPageSizeMax
+	var cursor logEventCursor
+	if pToken != nil {
+		if pToken.Size > 0 && pToken.Size < logPageSizeMax {
+			take = pToken.Size
+		}
+		var err error
+		cursor, err = parseLogEventCursor(pToken.Cursor)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var since time.Time
+	if earliestEvent != nil {
+		since = earliestEvent.AsTime()
+	}
+
+	logs, rateLimitData, err := f.client.GetLogs(ctx, cursor.LogId, since, take)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	events := make([]*v2.Event, 0, len(logs))
+	for _, log := range logs {
+		events = append(events, logEvents(log)...)
+	}
+
+	if len(logs) > 0 {
+		cursor.LogId = logs[len(logs)-1].LogId
+	}
+	hasMore := len(logs) == take
+
+	if f.updatedUsers && !hasMore {
+		userEvents, moreUsers, rateLimitData, err := f.updatedUserEvents(ctx, &cursor, since)
+		if err != nil {
+			if rateLimitData != nil {
+				outputAnnotations.WithRateLimiting(rateLimitData)
+			}
+			return nil, nil, outputAnnotations, wrapError(err)
+		}
+		outputAnnotations.WithRateLimiting(rateLimitData)
+		events = append(events, userEvents...)
+		hasMore = moreUsers
+	}
+
+	nextCursor, err := json.Marshal(cursor)
+	if err != nil {
+		return nil, nil, outputAnnotations, err
+	}
+
+	return events, &pagination.StreamState{
+		Cursor:  string(nextCursor),
+		HasMore: hasMore,
+	}, outputAnnotations, nil
+}
+
+// updatedUserEvents lists a page of the users updated since the high-water mark
+// held by the cursor's users pagination token, or since the earliest event wanted
+// on the first poll. Once the window is listed, the token starts over from its
+// end, less the search index lag, so users updated within the lag are reported
+// again by the next window, under the same event IDs. Returns whether the window
+// has more pages.
+func (f *logEventFeed) updatedUserEvents(
+	ctx context.Context,
+	cursor *logEventCursor,
+	earliestEvent time.Time,
+) ([]*v2.Event, bool, *v2.RateLimitDescription, error) {
+	token := cursor.Users
+	if token == "" {
+		since := earliestEvent
+		if since.IsZero() {
+			since = time.Now()
+		}
+		var err error
+		token, err = client2.NewUpdatedUsersToken(since)
+		if err != nil {
+			return nil, false, nil, err
+		}
+	}
+
+	window, limit, err := client2.ParseUserPaginationToken(&pagination.Token{Size: client2.PageSizeDefault, Token: token})
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	users, nextToken, rateLimitData, err := searchUsers(ctx, f.client, f.filter, window, limit)
+	if err != nil {
+		return nil, false, rateLimitData, err
+	}
+	events := make([]*v2.Event, 0, len(users))
+	for _, user := range users {
+		events = append(events, newUserUpdateEvent(user))
+	}
+
+	if nextToken != "" {
+		cursor.Users = nextToken
+		return events, true, rateLimitData, nil
+	}
+
+	highWaterMark, err := window.NextHighWaterMark()
+	if err != nil {
+		return nil, false, rateLimitData, err
+	}
+	cursor.Users, err = client2.NewUpdatedUsersToken(highWaterMark)
+	if err != nil {
+		return nil, false, rateLimitData, err
+	}
+	return events, false, rateLimitData, nil
+}
+
+// newUserUpdateEvent returns the change event of a user found by its updated_at
+// date. Its ID is derived from the update, so that an update found twice is
+// reported once.
+func newUserUpdateEvent(user client2.User) *v2.Event {
+	event := &v2.Event{
+		Id:         fmt.Sprintf("%s:%s:%s", userResourceType.Id, user.UserId, user.UpdatedAt.Format(time.RFC3339Nano)),
+		OccurredAt: timestamppb.New(user.UpdatedAt),
+	}
+	event.SetResourceChangeEvent(&v2.ResourceChangeEvent{
+		ResourceId: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: user.UserId},
+	})
+	return event
+}
+
+// logEvents maps a tenant log entry to events. Successful logins are usage events,
+// role and organization membership changes made through the Management API are
+// grant and revoke events, and user, role and organization changes are resource
+// change events. Any other entry maps to no events.
+func logEvents(log client2.Log) []*v2.Event {
+	switch log.Type {
+	case logTypeSuccessLogin:
+		if log.UserId == "" {
+			return nil
+		}
+		usage := &v2.UsageEvent{
+			ActorResource: &v2.Resource{
+				Id:          &v2.ResourceId{ResourceType: userResourceType.Id, Resource: log.UserId},
+				DisplayName: log.UserName,
+			},
+		}
+		if log.ClientId != "" {
+			usage.TargetResource = &v2.Resource{
+				Id:          &v2.ResourceId{ResourceType: applicationResourceType.Id, Resource: log.ClientId},
+				DisplayName: log.ClientName,
+			}
+		}
+		event := newLogEvent(log, 0)
+		event.SetUsageEvent(usage)
+		return []*v2.Event{event}
+
+	case logTypeSuccessSignup, logTypeSuccessUserDeletion:
+		if log.UserId == "" {
+			return nil
+		}
+		return []*v2.Event{newResourceChangeEvent(log, 0, userResourceType, log.UserId)}
+
+	case logTypeSuccessAPIOperation:
+		return apiOperationEvents(log)
+	}
+
+	return nil
+}
+
+// apiOperationBody holds the lists of IDs the membership endpoints take.
+type apiOperationBody struct {
+	Users   []string `json:"users"`
+	Roles   []string `json:"roles"`
+	Members []string `json:"members"`
+	UserId  string   `json:"user_id"`
+}
+
+// apiOperationEvents maps a successful Management API operation to events, based on
+// the request method and path.
+func apiOperationEvents(log client2.Log) []*v2.Event {
+	var operation client2.LogAPIOperation
+	if err := json.Unmarshal(log.Details, &operation); err != nil {
+		return nil
+	}
+
+	segments := apiOperationPath(operation.Request.Path)
+	if len(segments) == 0 {
+		return nil
+	}
+	method := strings.ToUpper(operation.Request.Method)
+	if method == http.MethodGet {
+		return nil
+	}
+
+	var body apiOperationBody
+	if len(operation.Request.Body) > 0 {
+		_ = json.Unmarshal(operation.Request.Body, &body)
+	}
+	isGrant := method == http.MethodPost
+	isRevoke := method == http.MethodDelete
+
+	switch {
+	// POST /users creates a user, whose ID is only known from the response.
+	case len(segments) == 1 && segments[0] == "users" && isGrant:
+		var created apiOperationBody
+		_ = json.Unmarshal(operation.Response.Body, &created)
+		if created.UserId == "" {
+			return nil
+		}
+		return []*v2.Event{newResourceChangeEvent(log, 0, userResourceType, created.UserId)}
+
+	case len(segments) == 2 && segments[0] == "users" && (method == http.MethodPatch || isRevoke):
+		return []*v2.Event{newResourceChangeEvent(log, 0, userResourceType, segments[1])}
+
+	// POST and DELETE /users/{id}/roles assign and remove roles from a user.
+	case len(segments) == 3 && segments[0] == "users" && segments[2] == "roles" && (isGrant || isRevoke):
+		events := make([]*v2.Event, 0, len(body.Roles))
+		for _, roleId := range body.Roles {
+			events = append(events, newMembershipEvent(
+				log, len(events), isGrant, roleResourceType, roleId, roleEntitlementName, segments[1],
+			))
+		}
+		return events
+
+	// POST /roles/{id}/users assigns a role to users.
+	case len(segments) == 3 && segments[0] == "roles" && segments[2] == "users" && isGrant:
+		events := make([]*v2.Event, 0, len(body.Users))
+		for _, userId := range body.Users {
+			events = append(events, newMembershipEvent(
+				log, len(events), true, roleResourceType, segments[1], roleEntitlementName, userId,
+			))
+		}
+		return events
+
+	case segments[0] == "roles" && len(segments) <= 2:
+		return resourceChangeEvents(log, roleResourceType, segments)
+
+	// POST and DELETE /organizations/{id}/members add and remove members.
+	case len(segments) == 3 && segments[0] == "organizations" && segments[2] == "members" && (isGrant || isRevoke):
+		events := make([]*v2.Event, 0, len(body.Members))
+		for _, userId := range body.Members {
+			events = append(events, newMembershipEvent(
+				log, len(events), isGrant, organizationResourceType, segments[1], organizationEntitlementName, userId,
+			))
+		}
+		return events
+
+	// POST and DELETE /organizations/{id}/members/{user_id}/roles assign and remove
+	// roles within an organization.
+	case len(segments) == 5 && segments[0] == "organizations" && segments[2] == "members" && segments[4] == "roles" &&
+		(isGrant || isRevoke):
+		events := make([]*v2.Event, 0, len(body.Roles))
+		for _, roleId := range body.Roles {
+			events = append(events, newMembershipEvent(
+				log, len(events), isGrant, organizationResourceType, segments[1], organizationRoleEntitlementName(roleId), segments[3],
+			))
+		}
+		return events
+
+	case segments[0] == "organizations" && len(segments) <= 2:
+		return resourceChangeEvents(log, organizationResourceType, segments)
+	}
+
+	return nil
+}
+
+// apiOperationPath splits a Management API request path into its unescaped
+// segments, without the /api/v2 prefix and the query string.
+func apiOperationPath(path string) []string {
+	path, _, _ = strings.Cut(path, "?")
+	path, ok := strings.CutPrefix(path, "/api/v2/")
+	if !ok {
+		return nil
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil
+		}
+		segments[i] = unescaped
+	}
+	return segments
+}
+
+// resourceChangeEvents maps an operation on /{collection}/{id} to a change of that
+// resource. Creations have no ID in the path, so their ID is read from the response.
+func resourceChangeEvents(log client2.Log, resourceType *v2.ResourceType, segments []string) []*v2.Event {
+	if len(segments) == 2 {
+		return []*v2.Event{newResourceChangeEvent(log, 0, resourceType, segments[1])}
+	}
+
+	var operation client2.LogAPIOperation
+	_ = json.Unmarshal(log.Details, &operation)
+	var created struct {
+		Id string `json:"id"`
+	}
+	_ = json.Unmarshal(operation.Response.Body, &created)
+	if created.Id == "" {
+		return nil
+	}
+	return []*v2.Event{newResourceChangeEvent(log, 0, resourceType, created.Id)}
+}
+
+// newLogEvent returns an event derived from a log entry. A log entry can map to
+// several events, which are told apart by their index.
+func newLogEvent(log client2.Log, index int) *v2.Event {
+	id := log.LogId
+	if index > 0 {
+		id = fmt.Sprintf("%s:%d", log.LogId, index)
+	}
+
+	return &v2.Event{
+		Id:         id,
+		OccurredAt: timestamppb.New(log.Date),
+	}
+}
+
+func newResourceChangeEvent(log client2.Log, index int, resourceType *v2.ResourceType, resourceId string) *v2.Event {
+	event := newLogEvent(log, index)
+	event.SetResourceChangeEvent(&v2.ResourceChangeEvent{
+		ResourceId: &v2.ResourceId{ResourceType: resourceType.Id, Resource: resourceId},
+	})
+	return event
+}
+
+func newMembershipEvent(
+	log client2.Log,
+	index int,
+	isGrant bool,
+	resourceType *v2.ResourceType,
+	resourceId string,
+	entitlementName string,
+	userId string,
+) *v2.Event {
+	entitlement := sdkEntitlement.NewAssignmentEntitlement(
+		&v2.Resource{Id: &v2.ResourceId{ResourceType: resourceType.Id, Resource: resourceId}},
+		entitlementName,
+		sdkEntitlement.WithGrantableTo(userResourceType),
+	)
+	principal := &v2.Resource{
+		Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: userId},
+	}
+
+	event := newLogEvent(log, index)
+	if isGrant {
+		event.SetCreateGrantEvent(&v2.CreateGrantEvent{Entitlement: entitlement, Principal: principal})
+	} else {
+		event.SetCreateRevokeEvent(&v2.CreateRevokeEvent{Entitlement: entitlement, Principal: principal})
+	}
+	return event
+}
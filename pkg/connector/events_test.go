@@ -0,0 +1,323 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	cfg "github.com/conductorone/baton-auth0/pkg/config"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// logServer serves the logs of a tenant and hands the user search to search.
+type logServer struct {
+	mu     sync.Mutex
+	logs   []client2.Log
+	search *userSearchServer
+}
+
+func (s *logServer) add(logs ...client2.Log) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, logs...)
+}
+
+func (s *logServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v2/logs" && !strings.HasPrefix(r.URL.Path, "/api/v2/logs/") {
+		s.search.ServeHTTP(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api/v2/logs":
+		from := r.URL.Query().Get("from")
+		for i, log := range s.logs {
+			if log.LogId == from {
+				_ = json.NewEncoder(w).Encode(s.logs[i+1:])
+				return
+			}
+		}
+		_ = json.NewEncoder(w).Encode(s.logs)
+	case strings.HasPrefix(r.URL.Path, "/api/v2/logs/"):
+		for _, log := range s.logs {
+			if r.URL.Path == "/api/v2/logs/"+log.LogId {
+				_ = json.NewEncoder(w).Encode(log)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// apiOperationLog returns a Management API operation log entry.
+func apiOperationLog(t *testing.T, method string, path string, requestBody string, responseBody string) client2.Log {
+	var operation client2.LogAPIOperation
+	operation.Request.Method = method
+	operation.Request.Path = path
+	if requestBody != "" {
+		operation.Request.Body = json.RawMessage(requestBody)
+	}
+	if responseBody != "" {
+		operation.Response.Body = json.RawMessage(responseBody)
+	}
+	details, err := json.Marshal(operation)
+	require.NoError(t, err)
+	return client2.Log{LogId: "log_1", Type: logTypeSuccessAPIOperation, Details: details}
+}
+
+// describeEvent returns the ID, type and subjects of an event.
+func describeEvent(event *v2.Event) string {
+	switch {
+	case event.GetUsageEvent() != nil:
+		usage := event.GetUsageEvent()
+		description := event.Id + " usage " + usage.GetActorResource().GetId().GetResource()
+		if usage.GetTargetResource() != nil {
+			description += " " + usage.GetTargetResource().GetId().GetResourceType() + ":" + usage.GetTargetResource().GetId().GetResource()
+		}
+		return description
+	case event.GetResourceChangeEvent() != nil:
+		resourceId := event.GetResourceChangeEvent().GetResourceId()
+		return event.Id + " change " + resourceId.GetResourceType() + ":" + resourceId.GetResource()
+	case event.GetCreateGrantEvent() != nil:
+		grant := event.GetCreateGrantEvent()
+		return event.Id + " grant " + grant.GetEntitlement().GetId() + " " + grant.GetPrincipal().GetId().GetResource()
+	case event.GetCreateRevokeEvent() != nil:
+		revoke := event.GetCreateRevokeEvent()
+		return event.Id + " revoke " + revoke.GetEntitlement().GetId() + " " + revoke.GetPrincipal().GetId().GetResource()
+	}
+	return event.Id + " unknown"
+}
+
+func TestLogEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		log    client2.Log
+		events []string
+	}{
+		{
+			name:   "login",
+			log:    client2.Log{LogId: "log_1", Type: logTypeSuccessLogin, UserId: "auth0|1", ClientId: "client_1"},
+			events: []string{"log_1 usage auth0|1 application:client_1"},
+		},
+		{
+			name: "login without a user",
+			log:  client2.Log{LogId: "log_1", Type: logTypeSuccessLogin},
+		},
+		{
+			name:   "signup",
+			log:    client2.Log{LogId: "log_1", Type: logTypeSuccessSignup, UserId: "auth0|1"},
+			events: []string{"log_1 change user:auth0|1"},
+		},
+		{
+			name:   "user deletion",
+			log:    client2.Log{LogId: "log_1", Type: logTypeSuccessUserDeletion, UserId: "auth0|1"},
+			events: []string{"log_1 change user:auth0|1"},
+		},
+		{
+			name: "failed login",
+			log:  client2.Log{LogId: "log_1", Type: "f", UserId: "auth0|1"},
+		},
+		{
+			name:   "user creation",
+			log:    apiOperationLog(t, "POST", "/api/v2/users", `{"email":"jane@example.com"}`, `{"user_id":"auth0|1"}`),
+			events: []string{"log_1 change user:auth0|1"},
+		},
+		{
+			name:   "user update",
+			log:    apiOperationLog(t, "PATCH", "/api/v2/users/auth0%7C1", `{"blocked":true}`, ""),
+			events: []string{"log_1 change user:auth0|1"},
+		},
+		{
+			name:   "user deletion through the API",
+			log:    apiOperationLog(t, "DELETE", "/api/v2/users/auth0%7C1", "", ""),
+			events: []string{"log_1 change user:auth0|1"},
+		},
+		{
+			name: "user roles assigned",
+			log:  apiOperationLog(t, "POST", "/api/v2/users/auth0%7C1/roles", `{"roles":["rol_1","rol_2"]}`, ""),
+			events: []string{
+				"log_1 grant role:rol_1:assigned auth0|1",
+				"log_1:1 grant role:rol_2:assigned auth0|1",
+			},
+		},
+		{
+			name:   "user roles removed",
+			log:    apiOperationLog(t, "DELETE", "/api/v2/users/auth0%7C1/roles", `{"roles":["rol_1"]}`, ""),
+			events: []string{"log_1 revoke role:rol_1:assigned auth0|1"},
+		},
+		{
+			name: "role users assigned",
+			log:  apiOperationLog(t, "POST", "/api/v2/roles/rol_1/users", `{"users":["auth0|1","auth0|2"]}`, ""),
+			events: []string{
+				"log_1 grant role:rol_1:assigned auth0|1",
+				"log_1:1 grant role:rol_1:assigned auth0|2",
+			},
+		},
+		{
+			name:   "role creation",
+			log:    apiOperationLog(t, "POST", "/api/v2/roles", `{"name":"Admin"}`, `{"id":"rol_1"}`),
+			events: []string{"log_1 change role:rol_1"},
+		},
+		{
+			name:   "role update",
+			log:    apiOperationLog(t, "PATCH", "/api/v2/roles/rol_1", `{"name":"Admin"}`, ""),
+			events: []string{"log_1 change role:rol_1"},
+		},
+		{
+			name:   "organization members added",
+			log:    apiOperationLog(t, "POST", "/api/v2/organizations/org_1/members", `{"members":["auth0|1"]}`, ""),
+			events: []string{"log_1 grant organization:org_1:member auth0|1"},
+		},
+		{
+			name:   "organization members removed",
+			log:    apiOperationLog(t, "DELETE", "/api/v2/organizations/org_1/members", `{"members":["auth0|1"]}`, ""),
+			events: []string{"log_1 revoke organization:org_1:member auth0|1"},
+		},
+		{
+			name:   "organization member roles assigned",
+			log:    apiOperationLog(t, "POST", "/api/v2/organizations/org_1/members/auth0%7C1/roles", `{"roles":["rol_1"]}`, ""),
+			events: []string{"log_1 grant organization:org_1:role:rol_1 auth0|1"},
+		},
+		{
+			name:   "organization update",
+			log:    apiOperationLog(t, "PATCH", "/api/v2/organizations/org_1", `{"display_name":"Acme"}`, ""),
+			events: []string{"log_1 change organization:org_1"},
+		},
+		{
+			name: "read",
+			log:  apiOperationLog(t, "GET", "/api/v2/users/auth0%7C1", "", ""),
+		},
+		{
+			name: "other API",
+			log:  apiOperationLog(t, "PATCH", "/api/v2/clients/client_1", `{"name":"App"}`, ""),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var events []string
+			for _, event := range logEvents(test.log) {
+				events = append(events, describeEvent(event))
+			}
+			require.Equal(t, test.events, events)
+		})
+	}
+}
+
+func TestLogEventFeed(t *testing.T) {
+	ctx := context.Background()
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tenant := &logServer{search: &userSearchServer{}}
+	tenant.add(client2.Log{LogId: "log_1", Date: date, Type: logTypeSuccessSignup, UserId: "auth0|1"})
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+
+	c0, err := client2.New(ctx, server.URL, "mock", "token")
+	require.NoError(t, err)
+
+	t.Run("polls", func(t *testing.T) {
+		feed := newLogEventFeed(c0, &resourceFilter{}, false)
+
+		events, state, _, err := feed.ListEvents(ctx, timestamppb.New(date.Add(-time.Hour)), &pagination.StreamToken{Size: 10})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.False(t, state.HasMore)
+		cursor, err := parseLogEventCursor(state.Cursor)
+		require.NoError(t, err)
+		require.Equal(t, "log_1", cursor.LogId)
+
+		// Polling from the same cursor returns nothing until a new entry is logged.
+		events, state, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		tenant.add(client2.Log{LogId: "log_2", Date: date.Add(time.Minute), Type: logTypeSuccessSignup, UserId: "auth0|2"})
+		events, state, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "log_2 change user:auth0|2", describeEvent(events[0]))
+		cursor, err = parseLogEventCursor(state.Cursor)
+		require.NoError(t, err)
+		require.Equal(t, "log_2", cursor.LogId)
+	})
+
+	t.Run("log ID cursor", func(t *testing.T) {
+		feed := newLogEventFeed(c0, &resourceFilter{}, false)
+
+		events, _, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: "log_1"})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "log_2 change user:auth0|2", describeEvent(events[0]))
+	})
+
+	t.Run("updated users", func(t *testing.T) {
+		filter, err := newResourceFilter(&cfg.Auth0{ExcludeConnections: []string{"customers"}})
+		require.NoError(t, err)
+		feed := newLogEventFeed(c0, filter, true)
+
+		updatedAt := time.Now().UTC().Add(-time.Hour)
+		tenant.search.add(
+			client2.User{UserId: "auth0|3", UpdatedAt: updatedAt.Add(-time.Hour)},
+			client2.User{
+				UserId:     "auth0|5",
+				UpdatedAt:  updatedAt.Add(time.Minute),
+				Identities: []client2.UserIdentities{{Connection: "customers"}},
+			},
+		)
+		for i := range client2.PageSizeDefault + 1 {
+			tenant.search.add(client2.User{
+				UserId:    fmt.Sprintf("auth0|updated_%03d", i),
+				UpdatedAt: updatedAt.Add(time.Duration(i+1) * time.Second),
+			})
+		}
+		users, err := client2.NewUpdatedUsersToken(updatedAt)
+		require.NoError(t, err)
+		cursor, err := json.Marshal(logEventCursor{LogId: "log_2", Users: users})
+		require.NoError(t, err)
+
+		// Only the users updated since the high-water mark and passing the filter
+		// are reported, a page per poll, without adding them to the principals of
+		// the sync.
+		events, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: string(cursor)})
+		require.NoError(t, err)
+		// The excluded user takes up a place in the page.
+		require.Len(t, events, client2.PageSizeDefault-1)
+		require.Equal(t, "user:auth0|updated_000:"+updatedAt.Add(time.Second).Format(time.RFC3339Nano)+" change user:auth0|updated_000", describeEvent(events[0]))
+		require.True(t, state.HasMore)
+		require.Empty(t, filter.principals)
+
+		// The next poll resumes the listing from the users pagination token.
+		events, state, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, "user:auth0|updated_100:"+updatedAt.Add(101*time.Second).Format(time.RFC3339Nano)+" change user:auth0|updated_100", describeEvent(events[1]))
+		require.False(t, state.HasMore)
+
+		// Once listed, the token holds the new high-water mark.
+		next, err := parseLogEventCursor(state.Cursor)
+		require.NoError(t, err)
+		require.Equal(t, "log_2", next.LogId)
+		window, _, err := client2.ParseUserPaginationToken(&pagination.Token{Token: next.Users})
+		require.NoError(t, err)
+		since, err := time.Parse(time.RFC3339Nano, window.Since)
+		require.NoError(t, err)
+		require.True(t, since.After(updatedAt.Add(time.Minute)))
+
+		events, _, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 10, Cursor: state.Cursor})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
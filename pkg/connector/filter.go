@@ -0,0 +1,270 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	cfg "github.com/conductorone/baton-auth0/pkg/config"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// organizationMetadataPrefix marks organization filter entries that match the
+// organizations having a metadata key, rather than an organization ID or name.
+const organizationMetadataPrefix = "metadata:"
+
+// principalSearchBatchSize is how many grant principals are looked up by one user
+// search, which keeps the search query well under Auth0's length limit.
+const principalSearchBatchSize = 50
+
+// resourceFilter restricts the users, connections, organizations and roles that are
+// synced. Empty include lists allow everything that isn't excluded.
+type resourceFilter struct {
+	// userQuery is a user search query fragment users must match.
+	userQuery            string
+	includeConnections   []string
+	excludeConnections   []string
+	includeOrganizations []string
+	excludeOrganizations []string
+	// includeRoles and excludeRoles are role name patterns, as matched by path.Match.
+	includeRoles []string
+	excludeRoles []string
+
+	mtx sync.Mutex
+	// principals caches whether the users named as grant principals pass the
+	// filter, since the same users appear in many grants. Listed users are added
+	// as they are listed, and the cache is dropped when a sync starts listing
+	// users, so it never outlives a sync.
+	principals map[string]bool
+}
+
+func newResourceFilter(config *cfg.Auth0) (*resourceFilter, error) {
+	for _, pattern := range slices.Concat(config.IncludeRoles, config.ExcludeRoles) {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("baton-auth0: invalid role name pattern %q: %w", pattern, err)
+		}
+	}
+
+	return &resourceFilter{
+		userQuery:            strings.TrimSpace(config.UserQuery),
+		includeConnections:   config.IncludeConnections,
+		excludeConnections:   config.ExcludeConnections,
+		includeOrganizations: config.IncludeOrganizations,
+		excludeOrganizations: config.ExcludeOrganizations,
+		includeRoles:         config.IncludeRoles,
+		excludeRoles:         config.ExcludeRoles,
+		principals:           make(map[string]bool),
+	}, nil
+}
+
+// filtersUsers reports whether some users are filtered out.
+func (f *resourceFilter) filtersUsers() bool {
+	return f.userQuery != "" || len(f.includeConnections) > 0 || len(f.excludeConnections) > 0
+}
+
+// usersQuery returns the user search query fragment matching the users that pass
+// the filter, or an empty string when no users are filtered out.
+func (f *resourceFilter) usersQuery() string {
+	var clauses []string
+	if f.userQuery != "" {
+		clauses = append(clauses, fmt.Sprintf("(%s)", f.userQuery))
+	}
+	if len(f.includeConnections) > 0 {
+		connections := make([]string, 0, len(f.includeConnections))
+		for _, connection := range f.includeConnections {
+			connections = append(connections, client2.QuoteSearchValue(connection))
+		}
+		clauses = append(clauses, fmt.Sprintf("identities.connection:(%s)", strings.Join(connections, " OR ")))
+	}
+	for _, connection := range f.excludeConnections {
+		clauses = append(clauses, fmt.Sprintf("NOT identities.connection:%s", client2.QuoteSearchValue(connection)))
+	}
+
+	return strings.Join(clauses, " AND ")
+}
+
+func (f *resourceFilter) allowsConnection(name string) bool {
+	if len(f.includeConnections) > 0 && !slices.Contains(f.includeConnections, name) {
+		return false
+	}
+	return !slices.Contains(f.excludeConnections, name)
+}
+
+// allowsUser reports whether the connections of a user pass the filter: the
+// connection of their primary identity must be synced, and no identity may be in an
+// excluded connection. The user query is applied by the user search instead.
+func (f *resourceFilter) allowsUser(user client2.User) bool {
+	if len(user.Identities) > 0 && !f.allowsConnection(user.Identities[0].Connection) {
+		return false
+	}
+	for _, identity := range user.Identities {
+		if slices.Contains(f.excludeConnections, identity.Connection) {
+			return false
+		}
+	}
+	return true
+}
+
+// resetPrincipals drops the principals cached by an earlier sync, whose users may
+// have since moved connections or been deleted.
+func (f *resourceFilter) resetPrincipals() {
+	f.mtx.Lock()
+	f.principals = make(map[string]bool)
+	f.mtx.Unlock()
+}
+
+// allowPrincipal records that a listed user passes the filter, so the grants
+// naming the user don't look it up again.
+func (f *resourceFilter) allowPrincipal(userId string) {
+	if !f.filtersUsers() {
+		return
+	}
+
+	f.mtx.Lock()
+	f.principals[userId] = true
+	f.mtx.Unlock()
+}
+
+// allowedPrincipals reports which of the users named as grant principals pass the
+// filter. Grants only carry user IDs, so the users this sync hasn't listed are
+// looked up in batches through the user search, which applies the user query.
+// Users missing from the search index, like those created moments ago, are fetched
+// by ID and checked against the connection filters when there is no user query.
+func (f *resourceFilter) allowedPrincipals(
+	ctx context.Context,
+	client *client2.Client,
+	userIds []string,
+) (map[string]bool, *v2.RateLimitDescription, error) {
+	allowed := make(map[string]bool, len(userIds))
+	if !f.filtersUsers() {
+		for _, userId := range userIds {
+			allowed[userId] = true
+		}
+		return allowed, nil, nil
+	}
+
+	var unknown []string
+	f.mtx.Lock()
+	for _, userId := range userIds {
+		if ok, cached := f.principals[userId]; cached {
+			allowed[userId] = ok
+		} else if !slices.Contains(unknown, userId) {
+			unknown = append(unknown, userId)
+		}
+	}
+	f.mtx.Unlock()
+
+	var rateLimitData *v2.RateLimitDescription
+	for batch := range slices.Chunk(unknown, principalSearchBatchSize) {
+		values := make([]string, 0, len(batch))
+		for _, userId := range batch {
+			values = append(values, client2.QuoteSearchValue(userId))
+		}
+		query := fmt.Sprintf("user_id:(%s)", strings.Join(values, " OR "))
+		users, _, rateLimitData0, err := client.GetUsers(ctx, len(batch), 0, query, client2.UsersSortUserId)
+		if rateLimitData0 != nil {
+			rateLimitData = rateLimitData0
+		}
+		if err != nil {
+			return nil, rateLimitData, err
+		}
+		for _, user := range users {
+			allowed[user.UserId] = f.allowsUser(user)
+		}
+
+		for _, userId := range batch {
+			if _, ok := allowed[userId]; ok || f.userQuery != "" {
+				continue
+			}
+			user, rateLimitData0, err := client.GetUser(ctx, userId)
+			if rateLimitData0 != nil {
+				rateLimitData = rateLimitData0
+			}
+			if err != nil {
+				if status.Code(err) == codes.NotFound {
+					continue
+				}
+				return nil, rateLimitData, err
+			}
+			allowed[userId] = f.allowsUser(*user)
+		}
+
+		f.mtx.Lock()
+		for _, userId := range batch {
+			f.principals[userId] = allowed[userId]
+		}
+		f.mtx.Unlock()
+	}
+
+	return allowed, rateLimitData, nil
+}
+
+// allowsFetchedUser reports whether a user fetched by ID passes the filter. Only
+// the user query needs the user search.
+func (f *resourceFilter) allowsFetchedUser(
+	ctx context.Context,
+	client *client2.Client,
+	user client2.User,
+) (bool, *v2.RateLimitDescription, error) {
+	if !f.allowsUser(user) {
+		return false, nil, nil
+	}
+	if f.userQuery == "" {
+		return true, nil, nil
+	}
+
+	allowed, rateLimitData, err := f.allowedPrincipals(ctx, client, []string{user.UserId})
+	if err != nil {
+		return false, rateLimitData, err
+	}
+	return allowed[user.UserId], rateLimitData, nil
+}
+
+func (f *resourceFilter) allowsOrganization(organization client2.Organization) bool {
+	if len(f.includeOrganizations) > 0 && !matchesOrganization(f.includeOrganizations, organization) {
+		return false
+	}
+	return !matchesOrganization(f.excludeOrganizations, organization)
+}
+
+// matchesOrganization reports whether any entry is the ID or name of the
+// organization, or a metadata key it has.
+func matchesOrganization(entries []string, organization client2.Organization) bool {
+	for _, entry := range entries {
+		if key, ok := strings.CutPrefix(entry, organizationMetadataPrefix); ok {
+			if _, ok := organization.Metadata[key]; ok {
+				return true
+			}
+			continue
+		}
+		if entry == organization.ID || entry == organization.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *resourceFilter) allowsRole(role client2.Role) bool {
+	if len(f.includeRoles) > 0 && !matchesRole(f.includeRoles, role) {
+		return false
+	}
+	return !matchesRole(f.excludeRoles, role)
+}
+
+// matchesRole reports whether the role name matches any of the patterns. Patterns
+// are validated when the filter is created.
+func matchesRole(patterns []string, role client2.Role) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, role.Name); matched {
+			return true
+		}
+	}
+	return false
+}
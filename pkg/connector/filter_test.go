@@ -0,0 +1,192 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	cfg "github.com/conductorone/baton-auth0/pkg/config"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceFilter(t *testing.T) {
+	filter, err := newResourceFilter(&cfg.Auth0{
+		UserQuery:            "app_metadata.workforce:true",
+		ExcludeConnections:   []string{"customers"},
+		IncludeOrganizations: []string{"org_1", "Acme", "metadata:workforce"},
+		ExcludeRoles:         []string{"Customer *"},
+	})
+	require.NoError(t, err)
+
+	t.Run("users query", func(t *testing.T) {
+		require.Equal(
+			t,
+			`(app_metadata.workforce:true) AND NOT identities.connection:"customers"`,
+			filter.usersQuery(),
+		)
+	})
+
+	t.Run("organizations and roles", func(t *testing.T) {
+		require.True(t, filter.allowsOrganization(client2.Organization{ID: "org_1"}))
+		require.True(t, filter.allowsOrganization(client2.Organization{ID: "org_2", Name: "Acme"}))
+		require.True(t, filter.allowsOrganization(client2.Organization{ID: "org_3", Metadata: map[string]string{"workforce": "yes"}}))
+		require.False(t, filter.allowsOrganization(client2.Organization{ID: "org_4", Name: "Other"}))
+
+		require.True(t, filter.allowsRole(client2.Role{Name: "Admin"}))
+		require.False(t, filter.allowsRole(client2.Role{Name: "Customer Support"}))
+	})
+
+	t.Run("invalid role pattern", func(t *testing.T) {
+		_, err := newResourceFilter(&cfg.Auth0{IncludeRoles: []string{"[Admin"}})
+		require.Error(t, err)
+	})
+
+	t.Run("grant principals", func(t *testing.T) {
+		tenant := newPrincipalServer(t, filter)
+		rb := newRoleBuilder(tenant.client, false, filter)
+
+		require.Equal(t, []string{"auth0|employee"}, rolePrincipals(t, rb))
+		// The users of a page are looked up by one search, and only once.
+		require.Equal(t, 1, tenant.searches)
+		require.Equal(t, []string{"auth0|employee"}, rolePrincipals(t, rb))
+		require.Equal(t, 1, tenant.searches)
+	})
+
+	t.Run("listed principals", func(t *testing.T) {
+		filter, err := newResourceFilter(&cfg.Auth0{ExcludeConnections: []string{"customers"}})
+		require.NoError(t, err)
+		tenant := newPrincipalServer(t, filter)
+		rb := newRoleBuilder(tenant.client, false, filter)
+
+		filter.allowPrincipal("auth0|employee")
+		filter.allowPrincipal("auth0|customer")
+		require.Equal(t, []string{"auth0|employee", "auth0|customer"}, rolePrincipals(t, rb))
+		require.Zero(t, tenant.searches)
+	})
+
+	t.Run("principals of an earlier sync", func(t *testing.T) {
+		filter, err := newResourceFilter(&cfg.Auth0{ExcludeConnections: []string{"customers"}})
+		require.NoError(t, err)
+		tenant := newPrincipalServer(t, filter)
+		rb := newRoleBuilder(tenant.client, false, filter)
+		ub := newUserBuilder(tenant.client, false, false, filter, userAttributeMapping{})
+
+		// The customer was listed by an earlier sync, before moving connections.
+		filter.allowPrincipal("auth0|customer")
+		filter.allowPrincipal("auth0|employee")
+		require.Equal(t, []string{"auth0|employee", "auth0|customer"}, rolePrincipals(t, rb))
+
+		// Listing the first page of users starts a new sync, which looks them up again.
+		_, _, _, err = ub.List(context.Background(), nil, &pagination.Token{})
+		require.NoError(t, err)
+		require.Empty(t, filter.principals)
+		require.Equal(t, []string{"auth0|employee"}, rolePrincipals(t, rb))
+	})
+
+	t.Run("principals missing from the search index", func(t *testing.T) {
+		filter, err := newResourceFilter(&cfg.Auth0{ExcludeConnections: []string{"customers"}})
+		require.NoError(t, err)
+		tenant := newPrincipalServer(t, filter)
+		tenant.unindexed = true
+		rb := newRoleBuilder(tenant.client, false, filter)
+
+		// The users are fetched by ID and their identities checked instead.
+		require.Equal(t, []string{"auth0|employee"}, rolePrincipals(t, rb))
+		require.Equal(t, 2, tenant.fetches)
+	})
+}
+
+// principalServer serves a role with an employee and a customer as its users, and
+// searches them like Auth0 does with the user query of a filter.
+type principalServer struct {
+	client *client2.Client
+	users  []client2.User
+	// unindexed leaves the users out of the search results.
+	unindexed bool
+	searches  int
+	fetches   int
+}
+
+var userIdSearchValue = regexp.MustCompile(`"([^"]+)"`)
+
+func newPrincipalServer(t *testing.T, filter *resourceFilter) *principalServer {
+	tenant := &principalServer{
+		users: []client2.User{
+			{UserId: "auth0|employee", Identities: []client2.UserIdentities{{Connection: "employees"}}},
+			{UserId: "auth0|customer", Identities: []client2.UserIdentities{{Connection: "customers"}}},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/oauth/token":
+			_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+		case r.URL.Path == "/api/v2/roles/rol_1/users":
+			_ = json.NewEncoder(w).Encode(client2.RolesUsersCheckpointResponse{Users: tenant.users})
+		case r.URL.Path == "/api/v2/users":
+			tenant.searches++
+			query := r.URL.Query().Get("q")
+			require.Contains(t, query, filter.usersQuery())
+			userIds, _, _ := strings.Cut(query, ")")
+			var found []client2.User
+			for _, match := range userIdSearchValue.FindAllStringSubmatch(userIds, -1) {
+				for _, user := range tenant.users {
+					// The filter excludes users in the customers connection.
+					if user.UserId == match[1] && !tenant.unindexed && user.Identities[0].Connection != "customers" {
+						found = append(found, user)
+					}
+				}
+			}
+			_ = json.NewEncoder(w).Encode(client2.UsersResponse{
+				PaginatedResponse: client2.PaginatedResponse{Total: len(found)},
+				Length:            len(found),
+				Users:             found,
+			})
+		case strings.HasPrefix(r.URL.Path, "/api/v2/users/"):
+			tenant.fetches++
+			for _, user := range tenant.users {
+				if r.URL.Path == "/api/v2/users/"+user.UserId {
+					_ = json.NewEncoder(w).Encode(user)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c0, err := client2.New(context.Background(), server.URL, "mock", "token", client2.WithUserQuery(filter.usersQuery()))
+	require.NoError(t, err)
+	tenant.client = c0
+	return tenant
+}
+
+// rolePrincipals returns the principals of the grants of the role served by a
+// principalServer.
+func rolePrincipals(t *testing.T, rb *roleBuilder) []string {
+	role := &v2.Resource{Id: &v2.ResourceId{ResourceType: roleResourceType.Id, Resource: "rol_1"}}
+
+	var principals []string
+	pToken := &pagination.Token{}
+	for range maxPages {
+		grants, nextToken, _, err := rb.Grants(context.Background(), role, pToken)
+		require.NoError(t, err)
+		for _, grant := range grants {
+			principals = append(principals, grant.Principal.Id.Resource)
+		}
+		if nextToken == "" {
+			break
+		}
+		pToken = &pagination.Token{Token: nextToken}
+	}
+	return principals
+}
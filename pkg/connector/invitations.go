@@ -0,0 +1,132 @@
+package connector
+
+import (
+	"context"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+var _ connectorbuilder.ResourceSyncer = (*invitationBuilder)(nil)
+
+type invitationBuilder struct {
+	client *client2.Client
+}
+
+func newInvitationBuilder(client *client2.Client) *invitationBuilder {
+	return &invitationBuilder{client: client}
+}
+
+func (b *invitationBuilder) ResourceType(_ context.Context) *v2.ResourceType {
+	return invitationResourceType
+}
+
+// Create a new connector resource for a pending Auth0 organization invitation.
+func invitationResource(invitation client2.OrganizationInvitation, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	roles := make([]interface{}, 0, len(invitation.Roles))
+	for _, roleId := range invitation.Roles {
+		roles = append(roles, roleId)
+	}
+
+	return resourceSdk.NewResource(
+		invitation.Invitee.Email,
+		invitationResourceType,
+		invitation.Id,
+		resourceSdk.WithResourceProfile(map[string]interface{}{
+			"id":              invitation.Id,
+			"organization_id": invitation.OrganizationId,
+			"inviter":         invitation.Inviter.Name,
+			"invitee_email":   invitation.Invitee.Email,
+			"client_id":       invitation.ClientId,
+			"connection_id":   invitation.ConnectionId,
+			"roles":           roles,
+			"created_at":      invitation.CreatedAt.Format(time.RFC3339),
+			"expires_at":      invitation.ExpiresAt.Format(time.RFC3339),
+		}),
+		resourceSdk.WithResourceCreatedAt(invitation.CreatedAt),
+		resourceSdk.WithParentResourceID(parentResourceID),
+	)
+}
+
+// List returns the pending invitations of an organization. Invitations are only
+// listed as children of organizations.
+func (b *invitationBuilder) List(
+	ctx context.Context,
+	parentResourceID *v2.ResourceId,
+	pToken *pagination.Token,
+) (
+	[]*v2.Resource,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	if parentResourceID == nil || parentResourceID.ResourceType != organizationResourceType.Id {
+		return nil, "", nil, nil
+	}
+
+	outputResources := make([]*v2.Resource, 0)
+	var outputAnnotations annotations.Annotations
+
+	page, limit, _, err := client2.ParsePaginationToken(pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	invitations, rateLimitData, err := b.client.GetOrganizationInvitations(
+		ctx,
+		parentResourceID.Resource,
+		limit,
+		page,
+	)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, "", outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	for _, invitation := range invitations {
+		invitationResource0, err := invitationResource(invitation, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		outputResources = append(outputResources, invitationResource0)
+	}
+
+	nextToken := client2.GetNextTokenWithoutTotal(page, limit, len(invitations))
+	return outputResources, nextToken, outputAnnotations, nil
+}
+
+// Entitlements always returns an empty slice for invitations.
+func (b *invitationBuilder) Entitlements(
+	_ context.Context,
+	_ *v2.Resource,
+	_ *pagination.Token,
+) (
+	[]*v2.Entitlement,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	return nil, "", nil, nil
+}
+
+// Grants always returns an empty slice for invitations since they don't have any entitlements.
+func (b *invitationBuilder) Grants(
+	_ context.Context,
+	_ *v2.Resource,
+	_ *pagination.Token,
+) (
+	[]*v2.Grant,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	return nil, "", nil, nil
+}
@@ -0,0 +1,443 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	logStreamEventFeedId = "auth0_log_stream"
+	// Auth0 sends at most 100 entries per webhook request, so a larger body is not a
+	// log stream batch.
+	logStreamBodySizeMax = 10 << 20
+)
+
+var _ connectorbuilder.EventFeed = (*logStreamEventFeed)(nil)
+
+// logStreamQueueCompactSize is how many bytes of consumed entries the queue
+// accumulates before they are dropped from it.
+const logStreamQueueCompactSize = 1 << 20
+
+// LogStreamQueue is a file of log stream entries, one JSON encoded log entry per
+// line. The receiver appends to it and the event feed reads it by offset, the
+// position of an entry among all the entries ever queued. Once the event feed has
+// consumed enough entries, they are dropped from the start of the file, which then
+// opens with a header line holding the offset of its first entry.
+//
+// The receiver and the connector run as separate processes, so appending and
+// compacting are serialized by a lock on a file next to the queue.
+type LogStreamQueue struct {
+	path string
+	mu   sync.Mutex
+	// compactSize is how many bytes of consumed entries are kept before compacting.
+	compactSize int64
+}
+
+func NewLogStreamQueue(path string) *LogStreamQueue {
+	return &LogStreamQueue{path: path, compactSize: logStreamQueueCompactSize}
+}
+
+// logStreamQueueHeader is the first line of a compacted queue file.
+type logStreamQueueHeader struct {
+	// Offset is the offset of the first entry in the file.
+	Offset *int64 `json:"queue_offset"`
+}
+
+// lock serializes the writes to the queue across processes, and returns the
+// function releasing the lock.
+func (q *LogStreamQueue) lock() (func(), error) {
+	q.mu.Lock()
+
+	file, err := os.OpenFile(q.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		q.mu.Unlock()
+		return nil, fmt.Errorf("baton-auth0: failed to open log stream queue lock: %w", err)
+	}
+	err = lockFile(file)
+	if err != nil {
+		_ = file.Close()
+		q.mu.Unlock()
+		return nil, fmt.Errorf("baton-auth0: failed to lock log stream queue: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+		q.mu.Unlock()
+	}, nil
+}
+
+// open opens the queue file and reads its header. It returns the offset of the
+// first entry and where the entries start in the file, positioned there.
+func (q *LogStreamQueue) open() (*os.File, int64, int64, error) {
+	file, err := os.Open(q.path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = file.Close()
+		return nil, 0, 0, err
+	}
+	var header logStreamQueueHeader
+	var start int64
+	if err == nil && json.Unmarshal(line, &header) == nil && header.Offset != nil {
+		start = int64(len(line))
+	} else {
+		header.Offset = new(int64)
+	}
+
+	_, err = file.Seek(start, io.SeekStart)
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, 0, err
+	}
+	return file, *header.Offset, start, nil
+}
+
+// Append writes the log entries to the end of the queue and flushes them to disk
+// before returning, so an acknowledged batch survives a crash.
+func (q *LogStreamQueue) Append(logs []client2.Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, log := range logs {
+		if err := encoder.Encode(log); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("baton-auth0: failed to open log stream queue: %w", err)
+	}
+	_, err = file.Write(buf.Bytes())
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("baton-auth0: failed to write log stream queue: %w", err)
+	}
+
+	return nil
+}
+
+// Read returns up to take log entries starting at the offset, along with the offset
+// to continue from. A line still being written is left for the next read.
+func (q *LogStreamQueue) Read(offset int64, take int) ([]client2.Log, int64, error) {
+	file, first, start, err := q.open()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, offset, nil
+		}
+		return nil, offset, fmt.Errorf("baton-auth0: failed to open log stream queue: %w", err)
+	}
+	defer file.Close()
+
+	if offset < first {
+		return nil, offset, fmt.Errorf("baton-auth0: the log stream entries from offset %d were already dropped from the queue", offset)
+	}
+	_, err = file.Seek(start+offset-first, io.SeekStart)
+	if err != nil {
+		return nil, offset, fmt.Errorf("baton-auth0: failed to read log stream queue: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	logs := make([]client2.Log, 0, take)
+	for len(logs) < take {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, offset, fmt.Errorf("baton-auth0: failed to read log stream queue: %w", err)
+		}
+		offset += int64(len(line))
+
+		var log client2.Log
+		if err := json.Unmarshal(line, &log); err != nil {
+			return nil, offset, fmt.Errorf("baton-auth0: invalid log stream queue entry: %w", err)
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, offset, nil
+}
+
+// Compact drops the entries before the offset, which the event feed consumed, once
+// they add up to the compact size. The remaining entries are copied to a new file
+// that replaces the queue.
+func (q *LogStreamQueue) Compact(offset int64) error {
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, first, start, err := q.open()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("baton-auth0: failed to open log stream queue: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("baton-auth0: failed to read log stream queue: %w", err)
+	}
+	if offset-first < q.compactSize || start+offset-first > info.Size() {
+		return nil
+	}
+
+	_, err = file.Seek(start+offset-first, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("baton-auth0: failed to read log stream queue: %w", err)
+	}
+	err = q.replace(offset, file)
+	if err != nil {
+		return fmt.Errorf("baton-auth0: failed to compact log stream queue: %w", err)
+	}
+
+	return nil
+}
+
+// replace atomically replaces the queue file with the entries read from entries,
+// the first of which is at the offset.
+func (q *LogStreamQueue) replace(offset int64, entries io.Reader) error {
+	file, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = json.NewEncoder(file).Encode(logStreamQueueHeader{Offset: &offset})
+	if err == nil {
+		_, err = io.Copy(file, entries)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), q.path)
+}
+
+// logStreamEntry is a log entry as sent by an Auth0 webhook log stream.
+type logStreamEntry struct {
+	LogId string       `json:"log_id"`
+	Data  *client2.Log `json:"data"`
+}
+
+// NewLogStreamHandler returns the handler receiving Auth0 webhook log stream
+// batches. Requests must carry the authorization header configured on the log
+// stream. Entries that map to events are appended to the queue; the others are
+// dropped.
+func NewLogStreamHandler(ctx context.Context, queue *LogStreamQueue, authorization string) http.Handler {
+	l := ctxzap.Extract(ctx)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(authorization)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, logStreamBodySizeMax))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		logs, err := parseLogStreamBatch(body)
+		if err != nil {
+			l.Warn("baton-auth0: rejected log stream batch", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		queued := make([]client2.Log, 0, len(logs))
+		for _, log := range logs {
+			if len(logEvents(log)) > 0 {
+				queued = append(queued, log)
+			}
+		}
+
+		// A failed write is reported so that Auth0 retries the batch.
+		err = queue.Append(queued)
+		if err != nil {
+			l.Error("baton-auth0: failed to queue log stream batch", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug(
+			"baton-auth0: received log stream batch",
+			zap.Int("received", len(logs)),
+			zap.Int("queued", len(queued)),
+		)
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// parseLogStreamBatch reads a batch in any of the log stream payload formats: a
+// JSON array, JSON lines or a single JSON object.
+func parseLogStreamBatch(body []byte) ([]client2.Log, error) {
+	body = bytes.TrimSpace(body)
+
+	var entries []logStreamEntry
+	if bytes.HasPrefix(body, []byte("[")) {
+		err := json.Unmarshal(body, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("baton-auth0: invalid log stream batch: %w", err)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		for decoder.More() {
+			var entry logStreamEntry
+			err := decoder.Decode(&entry)
+			if err != nil {
+				return nil, fmt.Errorf("baton-auth0: invalid log stream batch: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	logs := make([]client2.Log, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Data == nil {
+			return nil, fmt.Errorf("baton-auth0: log stream entry %q has no data", entry.LogId)
+		}
+		log := *entry.Data
+		if log.LogId == "" {
+			log.LogId = entry.LogId
+		}
+		if log.LogId == "" || log.Type == "" || log.Date.IsZero() {
+			return nil, fmt.Errorf("baton-auth0: log stream entry %q is missing its ID, type or date", entry.LogId)
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, nil
+}
+
+type logStreamEventFeed struct {
+	queue *LogStreamQueue
+}
+
+func newLogStreamEventFeed(queue *LogStreamQueue) *logStreamEventFeed {
+	return &logStreamEventFeed{queue: queue}
+}
+
+func (f *logStreamEventFeed) EventFeedMetadata(_ context.Context) *v2.EventFeedMetadata {
+	return &v2.EventFeedMetadata{
+		Id: logStreamEventFeedId,
+		SupportedEventTypes: []v2.EventType{
+			v2.EventType_EVENT_TYPE_USAGE,
+			v2.EventType_EVENT_TYPE_RESOURCE_CHANGE,
+			v2.EventType_EVENT_TYPE_CREATE_GRANT,
+			v2.EventType_EVENT_TYPE_CREATE_REVOKE,
+		},
+	}
+}
+
+// ListEvents reads the entries queued by the log stream receiver. The cursor is the
+// offset in the queue up to which entries were read.
+func (f *logStreamEventFeed) ListEvents(
+	ctx context.Context,
+	earliestEvent *timestamppb.Timestamp,
+	pToken *pagination.StreamToken,
+) (
+	[]*v2.Event,
+	*pagination.StreamState,
+	annotations.Annotations,
+	error,
+) {
+	take := logPageSizeMax
+	var offset int64
+	if pToken != nil {
+		if pToken.Size > 0 && pToken.Size < logPageSizeMax {
+			take = pToken.Size
+		}
+		if pToken.Cursor != "" {
+			var err error
+			offset, err = strconv.ParseInt(pToken.Cursor, 10, 64)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("baton-auth0: invalid log stream cursor: %w", err)
+			}
+		}
+	}
+
+	// Polling from a cursor means the entries before it were consumed.
+	err := f.queue.Compact(offset)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("baton-auth0: failed to compact log stream queue", zap.Error(err))
+	}
+
+	var since time.Time
+	if earliestEvent != nil {
+		since = earliestEvent.AsTime()
+	}
+
+	logs, offset, err := f.queue.Read(offset, take)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	events := make([]*v2.Event, 0, len(logs))
+	for _, log := range logs {
+		if log.Date.Before(since) {
+			continue
+		}
+		events = append(events, logEvents(log)...)
+	}
+
+	return events, &pagination.StreamState{
+		Cursor:  strconv.FormatInt(offset, 10),
+		HasMore: len(logs) == take,
+	}, nil, nil
+}
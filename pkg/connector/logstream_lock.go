@@ -0,0 +1,17 @@
+//go:build !windows
+
+package connector
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on the file, waiting for it if needed.
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX) //nolint:gosec // File descriptors fit in an int.
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN) //nolint:gosec // File descriptors fit in an int.
+}
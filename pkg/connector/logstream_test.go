@@ -0,0 +1,207 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogStreamBatch(t *testing.T) {
+	entry := func(logId string, data string) string {
+		return fmt.Sprintf(`{"log_id":%q,"data":%s}`, logId, data)
+	}
+	signup := `{"log_id":"log_1","date":"2024-01-01T00:00:00.000Z","type":"ss","user_id":"auth0|1"}`
+	login := `{"log_id":"log_2","date":"2024-01-01T00:01:00.000Z","type":"s","user_id":"auth0|2"}`
+
+	tests := []struct {
+		name string
+		body string
+		ids  []string
+		err  string
+	}{
+		{name: "JSON array", body: "[" + entry("log_1", signup) + "," + entry("log_2", login) + "]", ids: []string{"log_1", "log_2"}},
+		{name: "JSON lines", body: entry("log_1", signup) + "\n" + entry("log_2", login) + "\n", ids: []string{"log_1", "log_2"}},
+		{name: "single object", body: "  " + entry("log_1", signup) + "\n", ids: []string{"log_1"}},
+		{
+			name: "log ID of the entry",
+			body: entry("log_3", `{"date":"2024-01-01T00:00:00.000Z","type":"ss","user_id":"auth0|1"}`),
+			ids:  []string{"log_3"},
+		},
+		{name: "empty", body: "", ids: []string{}},
+		{name: "no data", body: `{"log_id":"log_1"}`, err: `log stream entry "log_1" has no data`},
+		{
+			name: "no type",
+			body: entry("log_1", `{"date":"2024-01-01T00:00:00.000Z"}`),
+			err:  `log stream entry "log_1" is missing its ID, type or date`,
+		},
+		{name: "invalid JSON array", body: "[" + entry("log_1", signup), err: "invalid log stream batch"},
+		{name: "invalid JSON lines", body: entry("log_1", signup) + "\n{", err: "invalid log stream batch"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs, err := parseLogStreamBatch([]byte(test.body))
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]string, 0, len(logs))
+			for _, log := range logs {
+				ids = append(ids, log.LogId)
+			}
+			require.Equal(t, test.ids, ids)
+		})
+	}
+}
+
+func TestLogStreamHandler(t *testing.T) {
+	ctx := context.Background()
+
+	queue := NewLogStreamQueue(filepath.Join(t.TempDir(), "queue.ndjson"))
+	server := httptest.NewServer(NewLogStreamHandler(ctx, queue, "Bearer secret"))
+	defer server.Close()
+
+	post := func(authorization string, body []byte) *http.Response {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		return response
+	}
+	batch := []byte(`[
+		{"log_id":"log_1","data":{"date":"2024-01-01T00:00:00.000Z","type":"ss","user_id":"auth0|1"}},
+		{"log_id":"log_2","data":{"date":"2024-01-01T00:01:00.000Z","type":"fp","user_id":"auth0|1"}}
+	]`)
+
+	t.Run("method", func(t *testing.T) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+		require.Equal(t, http.MethodPost, response.Header.Get("Allow"))
+	})
+
+	t.Run("authorization", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, post("", batch).StatusCode)
+		require.Equal(t, http.StatusUnauthorized, post("Bearer other", batch).StatusCode)
+		require.Equal(t, http.StatusUnauthorized, post("Bearer secret2", batch).StatusCode)
+
+		logs, _, err := queue.Read(0, 10)
+		require.NoError(t, err)
+		require.Empty(t, logs)
+	})
+
+	t.Run("body size", func(t *testing.T) {
+		body := append(bytes.Repeat([]byte(" "), logStreamBodySizeMax), batch...)
+		require.Equal(t, http.StatusRequestEntityTooLarge, post("Bearer secret", body).StatusCode)
+
+		// A body right at the cap is read.
+		body = append(bytes.Repeat([]byte(" "), logStreamBodySizeMax-len(batch)), batch...)
+		require.Equal(t, http.StatusOK, post("Bearer secret", body).StatusCode)
+		require.NoError(t, os.Remove(queue.path))
+	})
+
+	t.Run("invalid batch", func(t *testing.T) {
+		require.Equal(t, http.StatusBadRequest, post("Bearer secret", []byte(`{"log_id":"log_1"}`)).StatusCode)
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post("Bearer secret", batch).StatusCode)
+
+		// Only the entries that map to events are queued.
+		logs, _, err := queue.Read(0, 10)
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		require.Equal(t, "log_1", logs[0].LogId)
+	})
+}
+
+func TestLogStreamEventFeed(t *testing.T) {
+	ctx := context.Background()
+
+	signup := func(i int) client2.Log {
+		return client2.Log{
+			LogId:  fmt.Sprintf("log_%d", i),
+			Date:   time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+			Type:   logTypeSuccessSignup,
+			UserId: fmt.Sprintf("auth0|%d", i),
+		}
+	}
+	list := func(feed *logStreamEventFeed, cursor string) ([]string, *pagination.StreamState) {
+		events, state, _, err := feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 2, Cursor: cursor})
+		require.NoError(t, err)
+		descriptions := make([]string, 0, len(events))
+		for _, event := range events {
+			descriptions = append(descriptions, describeEvent(event))
+		}
+		return descriptions, state
+	}
+
+	queue := NewLogStreamQueue(filepath.Join(t.TempDir(), "queue.ndjson"))
+	queue.compactSize = 1
+	feed := newLogStreamEventFeed(queue)
+	require.NoError(t, queue.Append([]client2.Log{signup(1), signup(2), signup(3)}))
+
+	events, state := list(feed, "")
+	require.Equal(t, []string{"log_1 change user:auth0|1", "log_2 change user:auth0|2"}, events)
+	require.True(t, state.HasMore)
+	consumed, err := strconv.ParseInt(state.Cursor, 10, 64)
+	require.NoError(t, err)
+
+	// Polling from the cursor drops the entries before it from the queue.
+	events, state = list(feed, state.Cursor)
+	require.Equal(t, []string{"log_3 change user:auth0|3"}, events)
+	require.False(t, state.HasMore)
+	content, err := os.ReadFile(queue.path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, fmt.Sprintf(`{"queue_offset":%d}`, consumed), lines[0])
+	require.Contains(t, lines[1], `"log_id":"log_3"`)
+
+	// Offsets keep counting across compactions.
+	require.NoError(t, queue.Append([]client2.Log{signup(4)}))
+	events, state = list(feed, state.Cursor)
+	require.Equal(t, []string{"log_4 change user:auth0|4"}, events)
+	events, last := list(feed, state.Cursor)
+	require.Empty(t, events)
+	require.Equal(t, state.Cursor, last.Cursor)
+	content, err = os.ReadFile(queue.path)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("{\"queue_offset\":%s}\n", state.Cursor), string(content))
+
+	// The dropped entries can't be read again.
+	_, _, _, err = feed.ListEvents(ctx, nil, &pagination.StreamToken{Size: 2})
+	require.ErrorContains(t, err, "were already dropped from the queue")
+
+	t.Run("below the compact size", func(t *testing.T) {
+		queue := NewLogStreamQueue(filepath.Join(t.TempDir(), "queue.ndjson"))
+		feed := newLogStreamEventFeed(queue)
+		require.NoError(t, queue.Append([]client2.Log{signup(1), signup(2), signup(3)}))
+
+		_, state := list(feed, "")
+		events, _ := list(feed, state.Cursor)
+		require.Equal(t, []string{"log_3 change user:auth0|3"}, events)
+		logs, _, err := queue.Read(0, 10)
+		require.NoError(t, err)
+		require.Len(t, logs, 3)
+	})
+}
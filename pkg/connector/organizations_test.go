@@ -0,0 +1,380 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	cfg "github.com/conductorone/baton-auth0/pkg/config"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOrganizationGrantPrincipalTypes(t *testing.T) {
+	ctx := context.Background()
+
+	var changes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth/token" {
+			_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+			return
+		}
+		changes.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c0, err := client2.New(ctx, server.URL, "mock", "token")
+	require.NoError(t, err)
+	ob := newOrganizationBuilder(c0, nil, &resourceFilter{})
+
+	organization, err := organizationResource(client2.Organization{ID: "org_1", Name: "org"}, nil)
+	require.NoError(t, err)
+	entitlement := func(name string) *v2.Entitlement {
+		return sdkEntitlement.NewAssignmentEntitlement(organization, name)
+	}
+	user := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|1"}}
+	connection := &v2.Resource{Id: &v2.ResourceId{ResourceType: connectionResourceType.Id, Resource: "db"}}
+
+	for _, tc := range []struct {
+		name        string
+		principal   *v2.Resource
+		entitlement *v2.Entitlement
+	}{
+		{name: "connection as member", principal: connection, entitlement: entitlement(organizationEntitlementName)},
+		{name: "connection with role", principal: connection, entitlement: entitlement(organizationRoleEntitlementName("rol_1"))},
+		{name: "user as connection", principal: user, entitlement: entitlement(organizationConnectionEntitlementName)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ob.Grant(ctx, tc.principal, tc.entitlement)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+			_, err = ob.Revoke(ctx, &v2.Grant{Entitlement: tc.entitlement, Principal: tc.principal})
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+	require.Zero(t, changes.Load())
+
+	_, err = ob.Grant(ctx, user, entitlement(organizationEntitlementName))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), changes.Load())
+}
+
+// organizationServer serves the members of an organization along with their
+// organization roles, and the tenant roles. The organization has no connections.
+type organizationServer struct {
+	mu      sync.Mutex
+	members []client2.OrganizationMember
+	roles   []client2.Role
+}
+
+func (s *organizationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case r.URL.Path == "/api/v2/roles":
+		_ = json.NewEncoder(w).Encode(client2.RolesResponse{
+			Roles:             s.roles,
+			PaginatedResponse: client2.PaginatedResponse{Total: len(s.roles)},
+		})
+	case r.URL.Path == "/api/v2/organizations/org_1/members":
+		members := slices.Clone(s.members)
+		// Roles are only listed when asked for.
+		if !slices.Contains(strings.Split(r.URL.Query().Get("fields"), ","), "roles") {
+			for i := range members {
+				members[i].Roles = nil
+			}
+		}
+		_ = json.NewEncoder(w).Encode(client2.OrganizationMembersResponse{
+			Members:           members,
+			PaginatedResponse: client2.PaginatedResponse{Total: len(members)},
+		})
+	case r.URL.Path == "/api/v2/organizations/org_1/enabled_connections":
+		_, _ = w.Write([]byte(`{"enabled_connections":[],"total":0}`))
+	case strings.HasPrefix(r.URL.Path, "/api/v2/organizations/org_1/members/") && strings.HasSuffix(r.URL.Path, "/roles"):
+		userId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v2/organizations/org_1/members/"), "/roles")
+		var body struct {
+			Roles []string `json:"roles"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for i, member := range s.members {
+			if member.UserId != userId {
+				continue
+			}
+			for _, roleId := range body.Roles {
+				s.members[i].Roles = slices.DeleteFunc(s.members[i].Roles, func(role client2.Role) bool {
+					return role.ID == roleId
+				})
+				index := slices.IndexFunc(s.roles, func(role client2.Role) bool { return role.ID == roleId })
+				if r.Method == http.MethodPost && index >= 0 {
+					s.members[i].Roles = append(s.members[i].Roles, s.roles[index])
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// organizationGrants lists all the grants of an organization, as entitlement and
+// principal IDs.
+func organizationGrants(t *testing.T, ob *organizationBuilder, organization *v2.Resource) []string {
+	var grants []string
+	token := &pagination.Token{}
+	for {
+		page, nextToken, _, err := ob.Grants(context.Background(), organization, token)
+		require.NoError(t, err)
+		for _, grant := range page {
+			grants = append(grants, grant.Entitlement.Id+" "+grant.Principal.Id.Resource)
+		}
+		if nextToken == "" {
+			return grants
+		}
+		token = &pagination.Token{Token: nextToken}
+	}
+}
+
+func TestOrganizationMemberRoles(t *testing.T) {
+	ctx := context.Background()
+
+	tenant := &organizationServer{
+		roles: []client2.Role{{ID: "rol_1", Name: "Admin"}, {ID: "rol_2", Name: "Support"}},
+		members: []client2.OrganizationMember{
+			{User: client2.User{UserId: "auth0|1"}, Roles: []client2.Role{{ID: "rol_1", Name: "Admin"}}},
+			{User: client2.User{UserId: "auth0|2"}},
+		},
+	}
+	server := httptest.NewServer(tenant)
+	defer server.Close()
+
+	newBuilder := func(filter *resourceFilter) *organizationBuilder {
+		c0, err := client2.New(ctx, server.URL, "mock", "token")
+		require.NoError(t, err)
+		return newOrganizationBuilder(c0, nil, filter)
+	}
+	organization, err := organizationResource(client2.Organization{ID: "org_1", Name: "org"}, nil)
+	require.NoError(t, err)
+	user := func(userId string) *v2.Resource {
+		return &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: userId}}
+	}
+
+	ob := newBuilder(&resourceFilter{})
+	entitlements, _, _, err := ob.Entitlements(ctx, organization, &pagination.Token{})
+	require.NoError(t, err)
+	ids := make([]string, 0, len(entitlements))
+	for _, entitlement := range entitlements {
+		ids = append(ids, entitlement.Id)
+	}
+	require.Equal(t, []string{
+		"organization:org_1:member",
+		"organization:org_1:connection_enabled",
+		"organization:org_1:role:rol_1",
+		"organization:org_1:role:rol_2",
+	}, ids)
+	require.Equal(t, "org / Support", entitlements[3].DisplayName)
+
+	require.Equal(t, []string{
+		"organization:org_1:member auth0|1",
+		"organization:org_1:role:rol_1 auth0|1",
+		"organization:org_1:member auth0|2",
+	}, organizationGrants(t, ob, organization))
+
+	// The role entitlement listed grants the role within the organization, and the
+	// grant listed for it revokes it.
+	_, err = ob.Grant(ctx, user("auth0|2"), entitlements[3])
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"organization:org_1:member auth0|1",
+		"organization:org_1:role:rol_1 auth0|1",
+		"organization:org_1:member auth0|2",
+		"organization:org_1:role:rol_2 auth0|2",
+	}, organizationGrants(t, newBuilder(&resourceFilter{}), organization))
+
+	grants, _, _, err := newBuilder(&resourceFilter{}).memberGrants(ctx, organization, 0, 50)
+	require.NoError(t, err)
+	for _, grant := range grants {
+		if grant.Entitlement.Id == "organization:org_1:role:rol_1" {
+			_, err = ob.Revoke(ctx, grant)
+			require.NoError(t, err)
+		}
+	}
+	require.Equal(t, []string{
+		"organization:org_1:member auth0|1",
+		"organization:org_1:member auth0|2",
+		"organization:org_1:role:rol_2 auth0|2",
+	}, organizationGrants(t, newBuilder(&resourceFilter{}), organization))
+
+	t.Run("excluded role", func(t *testing.T) {
+		filter, err := newResourceFilter(&cfg.Auth0{ExcludeRoles: []string{"Support"}})
+		require.NoError(t, err)
+		ob := newBuilder(filter)
+
+		entitlements, _, _, err := ob.Entitlements(ctx, organization, &pagination.Token{})
+		require.NoError(t, err)
+		require.Len(t, entitlements, 3)
+		require.Equal(t, []string{
+			"organization:org_1:member auth0|1",
+			"organization:org_1:member auth0|2",
+		}, organizationGrants(t, ob, organization))
+	})
+}
+
+// invitationServer serves a user and the tenant settings, and records the
+// organization invitations. Each endpoint reports a rate limit of its own.
+type invitationServer struct {
+	mu           sync.Mutex
+	friendlyName string
+	settingsFail bool
+	invitations  []client2.OrganizationInvitation
+}
+
+func (s *invitationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+	w.Header().Set("X-RateLimit-Remaining", "100")
+	switch r.URL.Path {
+	case "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case "/api/v2/users/auth0|1":
+		w.Header().Set("X-RateLimit-Limit", "101")
+		_ = json.NewEncoder(w).Encode(client2.User{UserId: "auth0|1", Email: "jane@example.com"})
+	case "/api/v2/users/auth0|2":
+		w.Header().Set("X-RateLimit-Limit", "101")
+		_ = json.NewEncoder(w).Encode(client2.User{UserId: "auth0|2"})
+	case "/api/v2/tenants/settings":
+		w.Header().Set("X-RateLimit-Limit", "102")
+		if s.settingsFail {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"statusCode":403,"error":"Forbidden","message":"Insufficient scope, expected any of: read:tenant_settings","errorCode":"insufficient_scope"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(client2.TenantSettings{FriendlyName: s.friendlyName})
+	case "/api/v2/organizations/org_1/invitations":
+		w.Header().Set("X-RateLimit-Limit", "103")
+		var invitation client2.OrganizationInvitation
+		_ = json.NewDecoder(r.Body).Decode(&invitation)
+		s.invitations = append(s.invitations, invitation)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestOrganizationInvitation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		inviterName  string
+		friendlyName string
+		settingsFail bool
+		expected     func(server *httptest.Server) string
+	}{
+		{
+			name:        "configured inviter",
+			inviterName: "Acme IT",
+			expected:    func(*httptest.Server) string { return "Acme IT" },
+		},
+		{
+			name:         "tenant friendly name",
+			friendlyName: "Acme",
+			expected:     func(*httptest.Server) string { return "Acme" },
+		},
+		{
+			name:     "tenant without friendly name",
+			expected: func(server *httptest.Server) string { return strings.Split(server.Listener.Addr().String(), ":")[0] },
+		},
+		{
+			name:         "unreadable tenant settings",
+			settingsFail: true,
+			expected:     func(server *httptest.Server) string { return strings.Split(server.Listener.Addr().String(), ":")[0] },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant := &invitationServer{friendlyName: test.friendlyName, settingsFail: test.settingsFail}
+			server := httptest.NewServer(tenant)
+			defer server.Close()
+
+			c0, err := client2.New(ctx, server.URL, "mock", "token")
+			require.NoError(t, err)
+			ob := newOrganizationBuilder(c0, &organizationInvitationOptions{
+				clientId:    "client_1",
+				inviterName: test.inviterName,
+				roles:       []string{"rol_1"},
+			}, &resourceFilter{})
+
+			organization, err := organizationResource(client2.Organization{ID: "org_1", Name: "org"}, nil)
+			require.NoError(t, err)
+			user := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|1"}}
+			outputAnnotations, err := ob.Grant(ctx, user, sdkEntitlement.NewAssignmentEntitlement(organization, organizationEntitlementName))
+			require.NoError(t, err)
+
+			require.Len(t, tenant.invitations, 1)
+			invitation := tenant.invitations[0]
+			require.Equal(t, test.expected(server), invitation.Inviter.Name)
+			require.Equal(t, "jane@example.com", invitation.Invitee.Email)
+			require.Equal(t, "client_1", invitation.ClientId)
+			require.Equal(t, []string{"rol_1"}, invitation.Roles)
+
+			require.Equal(t, []int64{103}, rateLimits(outputAnnotations))
+		})
+	}
+
+	t.Run("user without email", func(t *testing.T) {
+		tenant := &invitationServer{}
+		server := httptest.NewServer(tenant)
+		defer server.Close()
+
+		c0, err := client2.New(ctx, server.URL, "mock", "token")
+		require.NoError(t, err)
+		ob := newOrganizationBuilder(c0, &organizationInvitationOptions{clientId: "client_1"}, &resourceFilter{})
+
+		organization, err := organizationResource(client2.Organization{ID: "org_1", Name: "org"}, nil)
+		require.NoError(t, err)
+		user := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|2"}}
+		outputAnnotations, err := ob.Grant(ctx, user, sdkEntitlement.NewAssignmentEntitlement(organization, organizationEntitlementName))
+		require.ErrorContains(t, err, "user auth0|2 has no email")
+		require.Empty(t, tenant.invitations)
+		// The rate limit of the user lookup is still reported.
+		require.Equal(t, []int64{101}, rateLimits(outputAnnotations))
+	})
+}
+
+// rateLimits returns the limits of the rate limit annotations.
+func rateLimits(outputAnnotations annotations.Annotations) []int64 {
+	var limits []int64
+	for _, annotation := range outputAnnotations {
+		var rateLimitData v2.RateLimitDescription
+		if annotation.UnmarshalTo(&rateLimitData) == nil {
+			limits = append(limits, rateLimitData.GetLimit())
+		}
+	}
+	return limits
+}
@@ -0,0 +1,120 @@
+package connector
+
+import (
+	"fmt"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/crypto"
+)
+
+const (
+	passwordLowerCaseLetters = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpperCaseLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordDigits           = "0123456789"
+	// The special characters Auth0 lists for its password policies.
+	passwordSpecialCharacters = "!@#$%^&*"
+
+	defaultPasswordLength = 16
+	// Passwords that break the excellent policy's repeated character rule are
+	// regenerated. With a random password this practically never takes more than one
+	// extra attempt.
+	passwordGenerationAttempts = 10
+)
+
+// passwordPolicy is the subset of an Auth0 database connection password policy that
+// a generated password has to satisfy. Dictionary, history and personal information
+// checks can't realistically be hit by a random password.
+type passwordPolicy struct {
+	minLength int
+	// requireSpecial is set by the good and excellent policies. Lower case, upper
+	// case and digits are always included.
+	requireSpecial bool
+	// maxRepeated is the number of identical characters allowed in a row, or 0 for
+	// no limit.
+	maxRepeated int
+}
+
+// newPasswordPolicy maps a connection's password policy to its requirements.
+// See https://auth0.com/docs/authenticate/database-connections/password-strength.
+func newPasswordPolicy(options *client2.ConnectionOptions) passwordPolicy {
+	var policy passwordPolicy
+	if options == nil {
+		return policy
+	}
+
+	switch options.PasswordPolicy {
+	case "low":
+		policy.minLength = 6
+	case "fair":
+		policy.minLength = 8
+	case "good":
+		policy.minLength = 8
+		policy.requireSpecial = true
+	case "excellent":
+		policy.minLength = 10
+		policy.requireSpecial = true
+		policy.maxRepeated = 2
+	}
+
+	if options.PasswordComplexityOptions != nil && options.PasswordComplexityOptions.MinLength > 0 {
+		policy.minLength = options.PasswordComplexityOptions.MinLength
+	}
+
+	return policy
+}
+
+// generatePassword returns a random password at least as long as requested that
+// satisfies the policy.
+func (p passwordPolicy) generatePassword(requested *v2.LocalCredentialOptions_RandomPassword) (string, error) {
+	length := int64(defaultPasswordLength)
+	if requested != nil && requested.GetLength() > 0 {
+		length = requested.GetLength()
+	}
+	if length < int64(p.minLength) {
+		length = int64(p.minLength)
+	}
+
+	constraints := []*v2.PasswordConstraint{
+		{CharSet: passwordLowerCaseLetters, MinCount: 1},
+		{CharSet: passwordUpperCaseLetters, MinCount: 1},
+		{CharSet: passwordDigits, MinCount: 1},
+	}
+	if p.requireSpecial {
+		constraints = append(constraints, &v2.PasswordConstraint{CharSet: passwordSpecialCharacters, MinCount: 1})
+	}
+	// Constraints requested by the caller are kept on top of the policy.
+	constraints = append(constraints, requested.GetConstraints()...)
+
+	options := &v2.LocalCredentialOptions_RandomPassword{
+		Length:      length,
+		Constraints: constraints,
+	}
+
+	for range passwordGenerationAttempts {
+		password, err := crypto.GenerateRandomPassword(options)
+		if err != nil {
+			return "", err
+		}
+		if p.maxRepeated == 0 || maxRepeatedCharacters(password) <= p.maxRepeated {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("baton-auth0: failed to generate a password that satisfies the connection password policy")
+}
+
+func maxRepeatedCharacters(password string) int {
+	longest, current := 0, 0
+	var previous rune
+	for i, character := range password {
+		if i > 0 && character == previous {
+			current++
+		} else {
+			current = 1
+		}
+		previous = character
+		longest = max(longest, current)
+	}
+	return longest
+}
@@ -39,7 +39,7 @@ func (b *resourceServerBuilder) List(ctx context.Context, _ *v2.ResourceId, pTok
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return nil, "", outputAnnotations, err
+		return nil, "", outputAnnotations, wrapError(err)
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -83,7 +83,7 @@ func (b *resourceServerBuilder) Grants(ctx context.Context, resource *v2.Resourc
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return nil, "", outputAnnotations, err
+		return nil, "", outputAnnotations, wrapError(err)
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -0,0 +1,49 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceServerBuilder(t *testing.T) {
+	ctx := context.Background()
+	_, c0 := newAPITenant(t)
+	rb := newResourceServerBuilder(c0)
+
+	resources, nextToken, _, err := rb.List(ctx, nil, &pagination.Token{Size: 1})
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.NotEmpty(t, nextToken)
+	require.Equal(t, "rs_1", resources[0].Id.Resource)
+	require.Equal(t, "Auth0 Management API", resources[0].DisplayName)
+
+	resources, nextToken, _, err = rb.List(ctx, nil, &pagination.Token{Size: 1, Token: nextToken})
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Empty(t, nextToken)
+	orders := resources[0]
+	require.Equal(t, "rs_2", orders.Id.Resource)
+
+	entitlements, _, _, err := rb.Entitlements(ctx, orders, nil)
+	require.NoError(t, err)
+	require.Len(t, entitlements, 1)
+	require.Equal(t, "resource_server:rs_2:scope", entitlements[0].Id)
+	require.Equal(t, []string{scopeResourceType.Id}, []string{entitlements[0].GrantableTo[0].Id})
+
+	// The grants of a resource server are its scopes, under the IDs the scope
+	// builder lists them with.
+	management := &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceServerResourceType.Id, Resource: "rs_1"}}
+	grants, _, _, err := rb.Grants(ctx, management, nil)
+	require.NoError(t, err)
+	scopes, _, _, err := newScopeBuilder(c0).List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	for i, grant := range grants {
+		require.Equal(t, "resource_server:rs_1:scope", grant.Entitlement.Id)
+		require.Equal(t, scopes[i].Id, grant.Principal.Id)
+	}
+}
@@ -19,13 +19,18 @@ var (
 		Id:          "user",
 		DisplayName: "User",
 		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
-		Annotations: skipEntitlementsAndGrants(),
 	}
 	organizationResourceType = &v2.ResourceType{
 		Id:          "organization",
 		DisplayName: "Organization",
 		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
 	}
+	invitationResourceType = &v2.ResourceType{
+		Id:          "invitation",
+		DisplayName: "Invitation",
+		Traits:      []v2.ResourceType_Trait{},
+		Annotations: skipEntitlementsAndGrants(),
+	}
 	roleResourceType = &v2.ResourceType{
 		Id:          "role",
 		DisplayName: "Role",
@@ -38,10 +43,22 @@ var (
 		Traits:      []v2.ResourceType_Trait{},
 	}
 
+	applicationResourceType = &v2.ResourceType{
+		Id:          "application",
+		DisplayName: "Application",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+		Annotations: skipEntitlementsAndGrants(),
+	}
+
+	connectionResourceType = &v2.ResourceType{
+		Id:          "connection",
+		DisplayName: "Connection",
+		Traits:      []v2.ResourceType_Trait{},
+	}
+
 	scopeResourceType = &v2.ResourceType{
 		Id:          "scope",
 		DisplayName: "Scope",
 		Traits:      []v2.ResourceType_Trait{},
-		Annotations: skipEntitlementsAndGrants(),
 	}
 )
@@ -14,11 +14,14 @@ import (
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	_ connectorbuilder.ResourceSyncer      = (*roleBuilder)(nil)
-	_ connectorbuilder.ResourceProvisioner = (*roleBuilder)(nil)
+	_ connectorbuilder.ResourceSyncer         = (*roleBuilder)(nil)
+	_ connectorbuilder.ResourceProvisioner    = (*roleBuilder)(nil)
+	_ connectorbuilder.ResourceTargetedSyncer = (*roleBuilder)(nil)
 )
 
 const roleEntitlementName = "assigned"
@@ -27,6 +30,7 @@ const rolePermissionEntitlementName = "has_permission"
 type roleBuilder struct {
 	client          *client2.Client
 	syncPermissions bool
+	filter          *resourceFilter
 }
 
 func (b *roleBuilder) ResourceType(_ context.Context) *v2.ResourceType {
@@ -74,7 +78,7 @@ func (b *roleBuilder) List(
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return nil, "", outputAnnotations, err
+		return nil, "", outputAnnotations, wrapError(err)
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -83,6 +87,9 @@ func (b *roleBuilder) List(
 	}
 
 	for _, role := range roles {
+		if !b.filter.allowsRole(role) {
+			continue
+		}
 		roleResource0, err := roleResource(role, parentResourceID)
 		if err != nil {
 			return nil, "", nil, err
@@ -95,6 +102,40 @@ func (b *roleBuilder) List(
 	return outputResources, nextToken, outputAnnotations, nil
 }
 
+// Get returns a single role, which lets targeted syncs re-fetch only the roles
+// that changed.
+func (b *roleBuilder) Get(
+	ctx context.Context,
+	resourceId *v2.ResourceId,
+	parentResourceId *v2.ResourceId,
+) (
+	*v2.Resource,
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+
+	role, rateLimitData, err := b.client.GetRole(ctx, resourceId.Resource)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if !b.filter.allowsRole(*role) {
+		return nil, outputAnnotations, status.Errorf(codes.NotFound, "baton-auth0: role %s is filtered out", role.ID)
+	}
+
+	resource, err := roleResource(*role, parentResourceId)
+	if err != nil {
+		return nil, outputAnnotations, err
+	}
+
+	return resource, outputAnnotations, nil
+}
+
 func (b *roleBuilder) Entitlements(
 	_ context.Context,
 	resource *v2.Resource,
@@ -198,7 +239,7 @@ func (b *roleBuilder) Grants(
 			if rateLimitData != nil {
 				outputAnnotations.WithRateLimiting(rateLimitData)
 			}
-			return nil, "", outputAnnotations, err
+			return nil, "", outputAnnotations, wrapError(err)
 		}
 		outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -206,8 +247,27 @@ func (b *roleBuilder) Grants(
 			return nil, "", outputAnnotations, nil
 		}
 
+		userIds := make([]string, 0, len(users))
+		for _, user := range users {
+			userIds = append(userIds, user.UserId)
+		}
+		allowed, rateLimitData, err := b.filter.allowedPrincipals(ctx, b.client, userIds)
+		if err != nil {
+			if rateLimitData != nil {
+				outputAnnotations.WithRateLimiting(rateLimitData)
+			}
+			return nil, "", outputAnnotations, wrapError(err)
+		}
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+
 		var grants []*v2.Grant
 		for _, user := range users {
+			if !allowed[user.UserId] {
+				continue
+			}
+
 			principalId, err := resourceSdk.NewResourceID(userResourceType, user.UserId)
 			if err != nil {
 				return nil, "", outputAnnotations, err
@@ -237,7 +297,7 @@ func (b *roleBuilder) Grants(
 			if rateLimitData != nil {
 				outputAnnotations.WithRateLimiting(rateLimitData)
 			}
-			return nil, "", outputAnnotations, err
+			return nil, "", outputAnnotations, wrapError(err)
 		}
 		outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -300,7 +360,7 @@ func (b *roleBuilder) Grant(
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return outputAnnotations, fmt.Errorf("baton-auth0: failed to add user to role: %w", err)
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to add user to role: %w", err))
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -329,16 +389,17 @@ func (b *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return outputAnnotations, fmt.Errorf("baton-auth0: failed to revoke membership to role: %w", err)
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to revoke membership to role: %w", err))
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
 	return outputAnnotations, nil
 }
 
-func newRoleBuilder(client *client2.Client, syncPermissions bool) *roleBuilder {
+func newRoleBuilder(client *client2.Client, syncPermissions bool, filter *resourceFilter) *roleBuilder {
 	return &roleBuilder{
 		client:          client,
 		syncPermissions: syncPermissions,
+		filter:          filter,
 	}
 }
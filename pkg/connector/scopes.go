@@ -3,16 +3,30 @@ package connector
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	client2 "github.com/conductorone/baton-auth0/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkEntitlement "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
-var _ connectorbuilder.ResourceSyncer = (*scopeBuilder)(nil)
+var (
+	_ connectorbuilder.ResourceSyncer      = (*scopeBuilder)(nil)
+	_ connectorbuilder.ResourceProvisioner = (*scopeBuilder)(nil)
+)
+
+const (
+	scopeEntitlementName            = "granted"
+	scopeClientGrantEntitlementName = "client_grant"
+)
 
 type scopeBuilder struct {
 	client *client2.Client
@@ -38,7 +52,7 @@ func (b *scopeBuilder) List(ctx context.Context, _ *v2.ResourceId, pToken *pagin
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return nil, "", outputAnnotations, err
+		return nil, "", outputAnnotations, wrapError(err)
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
@@ -62,12 +76,286 @@ func (b *scopeBuilder) List(ctx context.Context, _ *v2.ResourceId, pToken *pagin
 	return outputResources, nextToken, outputAnnotations, nil
 }
 
-func (b *scopeBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+func (b *scopeBuilder) Entitlements(_ context.Context, scope *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var privilegedSuffix, privilegedPrefix string
+	if resource.GetProfile(scope).GetFields()["privileged"].GetBoolValue() {
+		privilegedSuffix = " (privileged)"
+		privilegedPrefix = "Privileged Management API scope. "
+	}
+
+	return []*v2.Entitlement{
+		sdkEntitlement.NewPermissionEntitlement(
+			scope,
+			scopeEntitlementName,
+			sdkEntitlement.WithGrantableTo(userResourceType),
+			sdkEntitlement.WithDisplayName(
+				fmt.Sprintf("%s %s%s", scope.DisplayName, scopeEntitlementName, privilegedSuffix),
+			),
+			sdkEntitlement.WithDescription(
+				fmt.Sprintf("%sUser is directly assigned the %s permission in Auth0", privilegedPrefix, scope.DisplayName),
+			),
+		),
+		sdkEntitlement.NewPermissionEntitlement(
+			scope,
+			scopeClientGrantEntitlementName,
+			sdkEntitlement.WithGrantableTo(applicationResourceType),
+			sdkEntitlement.WithDisplayName(
+				fmt.Sprintf("%s client grant%s", scope.DisplayName, privilegedSuffix),
+			),
+			sdkEntitlement.WithDescription(
+				fmt.Sprintf("%sApplication is granted the %s scope through a client grant in Auth0", privilegedPrefix, scope.DisplayName),
+			),
+		),
+	}, "", nil, nil
 }
 
-func (b *scopeBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+// Grants returns one grant per application whose client grant on the scope's
+// resource server includes the scope. Direct user permissions are emitted by the
+// user builder, since Auth0 only lists them per user.
+func (b *scopeBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	audience, value, isSystem, outputAnnotations, err := b.scopeTarget(ctx, resource)
+	if err != nil {
+		return nil, "", outputAnnotations, err
+	}
+
+	page, limit, _, err := client2.ParsePaginationToken(pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	clientGrants, total, rateLimitData, err := b.client.GetClientGrants(ctx, audience, "", limit, page)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, "", outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if len(clientGrants) == 0 {
+		return nil, "", outputAnnotations, nil
+	}
+
+	var grants []*v2.Grant
+	for _, clientGrant := range clientGrants {
+		if !slices.Contains(clientGrant.Scope, value) {
+			continue
+		}
+
+		grants = append(grants, sdkGrant.NewGrant(
+			resource,
+			scopeClientGrantEntitlementName,
+			&v2.ResourceId{
+				ResourceType: applicationResourceType.Id,
+				Resource:     clientGrant.ClientId,
+			},
+			sdkGrant.WithGrantMetadata(map[string]interface{}{
+				"client_grant_id": clientGrant.Id,
+				"privileged":      isSystem,
+			}),
+		))
+	}
+
+	nextToken := client2.GetNextToken(page, limit, total)
+	return grants, nextToken, outputAnnotations, nil
+}
+
+// Grant assigns the scope directly to a user, or adds it to an application's
+// client grant for the scope's resource server.
+func (b *scopeBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	switch principal.Id.ResourceType {
+	case userResourceType.Id:
+		return b.grantUserPermission(ctx, principal.Id.Resource, entitlement.Resource)
+	case applicationResourceType.Id:
+		return b.grantClientGrantScope(ctx, principal.Id.Resource, entitlement.Resource)
+	default:
+		l.Warn(
+			"baton-auth0: only users and applications can be granted scopes",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("baton-auth0: only users and applications can be granted scopes")
+	}
+}
+
+func (b *scopeBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	principal := grant.Principal
+	switch principal.Id.ResourceType {
+	case userResourceType.Id:
+		return b.revokeUserPermission(ctx, principal.Id.Resource, grant.Entitlement.Resource)
+	case applicationResourceType.Id:
+		return b.revokeClientGrantScope(ctx, principal.Id.Resource, grant.Entitlement.Resource)
+	default:
+		l.Warn(
+			"baton-auth0: only users and applications can have scopes revoked",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("baton-auth0: only users and applications can have scopes revoked")
+	}
+}
+
+func (b *scopeBuilder) grantUserPermission(ctx context.Context, userId string, scope *v2.Resource) (annotations.Annotations, error) {
+	audience, value, _, outputAnnotations, err := b.scopeTarget(ctx, scope)
+	if err != nil {
+		return outputAnnotations, err
+	}
+
+	rateLimitData, err := b.client.AddPermissionToUser(ctx, userId, audience, value)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to add permission to user: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return outputAnnotations, nil
+}
+
+func (b *scopeBuilder) revokeUserPermission(ctx context.Context, userId string, scope *v2.Resource) (annotations.Annotations, error) {
+	audience, value, _, outputAnnotations, err := b.scopeTarget(ctx, scope)
+	if err != nil {
+		return outputAnnotations, err
+	}
+
+	rateLimitData, err := b.client.RemovePermissionFromUser(ctx, userId, audience, value)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to remove permission from user: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return outputAnnotations, nil
+}
+
+// grantClientGrantScope adds the scope to the application's client grant for the
+// scope's resource server, creating the client grant if the application has none yet.
+func (b *scopeBuilder) grantClientGrantScope(ctx context.Context, clientId string, scope *v2.Resource) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	audience, value, isSystem, outputAnnotations, err := b.scopeTarget(ctx, scope)
+	if err != nil {
+		return outputAnnotations, err
+	}
+	if isSystem {
+		l.Info(
+			"baton-auth0: granting privileged Management API scope to application",
+			zap.String("client_id", clientId),
+			zap.String("scope", value),
+		)
+	}
+
+	clientGrant, rateLimitData, err := b.client.GetClientGrant(ctx, audience, clientId)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to get client grant: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	switch {
+	case clientGrant == nil:
+		rateLimitData, err = b.client.CreateClientGrant(ctx, clientId, audience, []string{value})
+	case slices.Contains(clientGrant.Scope, value):
+		outputAnnotations.Append(&v2.GrantAlreadyExists{})
+		return outputAnnotations, nil
+	default:
+		rateLimitData, err = b.client.UpdateClientGrantScope(ctx, clientGrant.Id, append(clientGrant.Scope, value))
+	}
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to grant scope to application: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return outputAnnotations, nil
+}
+
+// revokeClientGrantScope removes the scope from the application's client grant,
+// deleting the client grant when it was the last scope left on it.
+func (b *scopeBuilder) revokeClientGrantScope(ctx context.Context, clientId string, scope *v2.Resource) (annotations.Annotations, error) {
+	audience, value, _, outputAnnotations, err := b.scopeTarget(ctx, scope)
+	if err != nil {
+		return outputAnnotations, err
+	}
+
+	clientGrant, rateLimitData, err := b.client.GetClientGrant(ctx, audience, clientId)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to get client grant: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if clientGrant == nil || !slices.Contains(clientGrant.Scope, value) {
+		outputAnnotations.Append(&v2.GrantAlreadyRevoked{})
+		return outputAnnotations, nil
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(clientGrant.Scope), func(scope string) bool {
+		return scope == value
+	})
+	if len(remaining) == 0 {
+		rateLimitData, err = b.client.DeleteClientGrant(ctx, clientGrant.Id)
+	} else {
+		rateLimitData, err = b.client.UpdateClientGrantScope(ctx, clientGrant.Id, remaining)
+	}
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to revoke scope from application: %w", err))
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return outputAnnotations, nil
+}
+
+// scopeTarget returns the resource server audience and scope value a scope resource
+// refers to, and whether the resource server is a system one (the Management API).
+// The profile is used when present; otherwise the parent resource server is fetched.
+func (b *scopeBuilder) scopeTarget(
+	ctx context.Context,
+	scope *v2.Resource,
+) (string, string, bool, annotations.Annotations, error) {
+	var outputAnnotations annotations.Annotations
+
+	profile := resource.GetProfile(scope)
+	audience, hasAudience := resource.GetProfileStringValue(profile, "audience")
+	value, hasValue := resource.GetProfileStringValue(profile, "value")
+	if hasAudience && hasValue {
+		isSystem := profile.GetFields()["is_system"].GetBoolValue()
+		return audience, value, isSystem, outputAnnotations, nil
+	}
+
+	parent := scope.GetParentResourceId()
+	if parent == nil || parent.ResourceType != resourceServerResourceType.Id {
+		return "", "", false, outputAnnotations, fmt.Errorf("baton-auth0: scope %s has no resource server", scope.Id.Resource)
+	}
+
+	server, rateLimitData, err := b.client.GetResourceServer(ctx, parent.Resource)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return "", "", false, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	prefix := server.Identifier + ":"
+	if !strings.HasPrefix(scope.Id.Resource, prefix) {
+		return "", "", false, outputAnnotations, fmt.Errorf("baton-auth0: scope %s does not belong to resource server %s", scope.Id.Resource, server.Id)
+	}
+
+	return server.Identifier, strings.TrimPrefix(scope.Id.Resource, prefix), server.IsSystem, outputAnnotations, nil
 }
 
 func scopeResource(resourceServer client2.ResourceServerScope, server *client2.ResourceServer) (*v2.Resource, error) {
@@ -81,6 +369,15 @@ func scopeResource(resourceServer client2.ResourceServerScope, server *client2.R
 		scopeResourceType,
 		scopeId,
 		resource.WithDescription(resourceServer.Description),
+		resource.WithResourceProfile(map[string]interface{}{
+			"resource_server_id": server.Id,
+			"audience":           server.Identifier,
+			"value":              resourceServer.Value,
+			"is_system":          server.IsSystem,
+			// Scopes of system resource servers (the Management API) give control
+			// over the tenant itself, so they and their entitlements are privileged.
+			"privileged": server.IsSystem,
+		}),
 		resource.WithParentResourceID(&v2.ResourceId{
 			ResourceType: resourceServerResourceType.Id,
 			Resource:     server.Id,
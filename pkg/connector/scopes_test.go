@@ -0,0 +1,255 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeEntitlementsPrivileged(t *testing.T) {
+	ctx := context.Background()
+	sb := newScopeBuilder(nil)
+
+	for _, isSystem := range []bool{false, true} {
+		scope, err := scopeResource(
+			client2.ResourceServerScope{Value: "delete:users"},
+			&client2.ResourceServer{Id: "rs_1", Name: "Auth0 Management API", Identifier: "https://tenant/api/v2/", IsSystem: isSystem},
+		)
+		require.NoError(t, err)
+
+		require.Equal(t, isSystem, resource.GetProfile(scope).GetFields()["privileged"].GetBoolValue())
+
+		entitlements, _, _, err := sb.Entitlements(ctx, scope, nil)
+		require.NoError(t, err)
+		require.Len(t, entitlements, 2)
+		for _, entitlement := range entitlements {
+			require.Equal(t, isSystem, strings.HasSuffix(entitlement.DisplayName, "(privileged)"), entitlement.DisplayName)
+			require.Equal(t, isSystem, strings.HasPrefix(entitlement.Description, "Privileged"), entitlement.Description)
+		}
+	}
+}
+
+// clientGrantServer serves the client grants of a tenant, keeping the scopes
+// patched onto them.
+type clientGrantServer struct {
+	mu           sync.Mutex
+	clientGrants []client2.ClientGrant
+	patches      [][]string
+	deleted      []string
+}
+
+func (s *clientGrantServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/client-grants":
+		var found []client2.ClientGrant
+		for _, clientGrant := range s.clientGrants {
+			if clientGrant.Audience == r.URL.Query().Get("audience") && clientGrant.ClientId == r.URL.Query().Get("client_id") {
+				found = append(found, clientGrant)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(client2.ClientGrantsResponse{
+			PaginatedResponse: client2.PaginatedResponse{Total: len(found)},
+			ClientGrants:      found,
+		})
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/api/v2/client-grants/"):
+		var body struct {
+			Scope []string `json:"scope"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.patches = append(s.patches, body.Scope)
+		for i := range s.clientGrants {
+			if r.URL.Path == "/api/v2/client-grants/"+s.clientGrants[i].Id {
+				s.clientGrants[i].Scope = body.Scope
+			}
+		}
+		_, _ = w.Write([]byte("{}"))
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/client-grants/"):
+		s.deleted = append(s.deleted, strings.TrimPrefix(r.URL.Path, "/api/v2/client-grants/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestScopeClientGrants(t *testing.T) {
+	ctx := context.Background()
+
+	server := &client2.ResourceServer{Id: "rs_1", Name: "API", Identifier: "https://api.example.com"}
+	scopeEntitlement := func(t *testing.T, value string) *v2.Entitlement {
+		scope, err := scopeResource(client2.ResourceServerScope{Value: value}, server)
+		require.NoError(t, err)
+		return &v2.Entitlement{Resource: scope}
+	}
+	application := &v2.Resource{Id: &v2.ResourceId{ResourceType: applicationResourceType.Id, Resource: "client_1"}}
+
+	newServer := func(t *testing.T) (*clientGrantServer, *scopeBuilder) {
+		tenant := &clientGrantServer{
+			clientGrants: []client2.ClientGrant{
+				{Id: "cgr_1", ClientId: "client_1", Audience: server.Identifier, Scope: []string{"read:a"}},
+			},
+		}
+		httpServer := httptest.NewServer(tenant)
+		t.Cleanup(httpServer.Close)
+		c0, err := client2.New(ctx, httpServer.URL, "mock", "token")
+		require.NoError(t, err)
+		return tenant, newScopeBuilder(c0)
+	}
+
+	t.Run("grants in sequence", func(t *testing.T) {
+		tenant, sb := newServer(t)
+
+		_, err := sb.Grant(ctx, application, scopeEntitlement(t, "read:b"))
+		require.NoError(t, err)
+		_, err = sb.Grant(ctx, application, scopeEntitlement(t, "read:c"))
+		require.NoError(t, err)
+
+		// Each grant reads the scopes left by the previous one.
+		require.Equal(t, [][]string{{"read:a", "read:b"}, {"read:a", "read:b", "read:c"}}, tenant.patches)
+	})
+
+	t.Run("revokes in sequence", func(t *testing.T) {
+		tenant, sb := newServer(t)
+		tenant.clientGrants[0].Scope = []string{"read:a", "read:b", "read:c"}
+
+		for _, value := range []string{"read:b", "read:c", "read:a"} {
+			_, err := sb.Revoke(ctx, &v2.Grant{Entitlement: scopeEntitlement(t, value), Principal: application})
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, [][]string{{"read:a", "read:c"}, {"read:a"}}, tenant.patches)
+		// The client grant is deleted with its last scope.
+		require.Equal(t, []string{"cgr_1"}, tenant.deleted)
+	})
+}
+
+func TestScopeBuilder(t *testing.T) {
+	ctx := context.Background()
+	_, c0 := newAPITenant(t)
+	sb := newScopeBuilder(c0)
+
+	scopes, nextToken, _, err := sb.List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, nextToken)
+	ids := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		ids = append(ids, scope.Id.Resource+" "+scope.DisplayName+" "+scope.ParentResourceId.Resource)
+	}
+	require.Equal(t, []string{
+		"https://tenant.auth0.com/api/v2/:read:users Auth0 Management API/read:users rs_1",
+		"https://tenant.auth0.com/api/v2/:delete:users Auth0 Management API/delete:users rs_1",
+		"https://orders.example.com:read:orders Orders API/read:orders rs_2",
+	}, ids)
+	require.Equal(t, "Read Users", scopes[0].Description)
+	profile := resource.GetProfile(scopes[0])
+	audience, _ := resource.GetProfileStringValue(profile, "audience")
+	require.Equal(t, "https://tenant.auth0.com/api/v2/", audience)
+	value, _ := resource.GetProfileStringValue(profile, "value")
+	require.Equal(t, "read:users", value)
+
+	entitlements, _, _, err := sb.Entitlements(ctx, scopes[2], nil)
+	require.NoError(t, err)
+	require.Len(t, entitlements, 2)
+	require.Equal(t, "scope:https://orders.example.com:read:orders:granted", entitlements[0].Id)
+	require.Equal(t, userResourceType.Id, entitlements[0].GrantableTo[0].Id)
+	require.Equal(t, "scope:https://orders.example.com:read:orders:client_grant", entitlements[1].Id)
+	require.Equal(t, applicationResourceType.Id, entitlements[1].GrantableTo[0].Id)
+
+	// Applications get a grant per client grant that includes the scope.
+	grantees := func(scope *v2.Resource, size int) []string {
+		var principals []string
+		token := &pagination.Token{Size: size}
+		for {
+			grants, nextToken, _, err := sb.Grants(ctx, scope, token)
+			require.NoError(t, err)
+			for _, grant := range grants {
+				require.Equal(t, "scope:"+scope.Id.Resource+":client_grant", grant.Entitlement.Id)
+				principals = append(principals, grant.Principal.Id.ResourceType+":"+grant.Principal.Id.Resource)
+			}
+			if nextToken == "" {
+				return principals
+			}
+			token = &pagination.Token{Size: size, Token: nextToken}
+		}
+	}
+	require.Equal(t, []string{"application:client_2", "application:client_1"}, grantees(scopes[0], 1))
+	require.Equal(t, []string{"application:client_2"}, grantees(scopes[1], 50))
+	require.Empty(t, grantees(scopes[2], 50))
+
+	// The grant records its client grant, and whether the scope is privileged.
+	grants, _, _, err := sb.Grants(ctx, scopes[1], nil)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	var metadata v2.GrantMetadata
+	grantAnnotations := annotations.Annotations(grants[0].Annotations)
+	ok, err := grantAnnotations.Pick(&metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "cgr_1", metadata.GetMetadata().GetFields()["client_grant_id"].GetStringValue())
+	require.True(t, metadata.GetMetadata().GetFields()["privileged"].GetBoolValue())
+}
+
+func TestUserPermissions(t *testing.T) {
+	ctx := context.Background()
+	tenant, c0 := newAPITenant(t)
+	tenant.permissions["auth0|1"] = []client2.RolePermission{
+		{ResourceServerIdentifier: "https://orders.example.com", PermissionName: "read:orders"},
+	}
+
+	scopes, _, _, err := newScopeBuilder(c0).List(ctx, nil, nil)
+	require.NoError(t, err)
+	readUsers, readOrders := scopes[0], scopes[2]
+	user := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|1"}}
+
+	// Responses are cached per client, so each check reads with a new one.
+	granted := func() []string {
+		c, err := client2.New(ctx, tenant.url, "mock", "token")
+		require.NoError(t, err)
+		grants, _, _, err := newUserBuilder(c, true, false, &resourceFilter{}, userAttributeMapping{}).Grants(ctx, user, nil)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(grants))
+		for _, grant := range grants {
+			require.Equal(t, user.Id.Resource, grant.Principal.Id.Resource)
+			ids = append(ids, grant.Entitlement.Id)
+		}
+		return ids
+	}
+	require.Equal(t, []string{"scope:https://orders.example.com:read:orders:granted"}, granted())
+
+	// Without syncing permissions, the user gets no scope grants.
+	grants, _, _, err := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{}).Grants(ctx, user, nil)
+	require.NoError(t, err)
+	require.Empty(t, grants)
+
+	sb := newScopeBuilder(c0)
+	entitlements, _, _, err := sb.Entitlements(ctx, readUsers, nil)
+	require.NoError(t, err)
+	_, err = sb.Grant(ctx, user, entitlements[0])
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"scope:https://orders.example.com:read:orders:granted",
+		"scope:https://tenant.auth0.com/api/v2/:read:users:granted",
+	}, granted())
+
+	entitlements, _, _, err = sb.Entitlements(ctx, readOrders, nil)
+	require.NoError(t, err)
+	_, err = sb.Revoke(ctx, &v2.Grant{Entitlement: entitlements[0], Principal: user})
+	require.NoError(t, err)
+	require.Equal(t, []string{"scope:https://tenant.auth0.com/api/v2/:read:users:granted"}, granted())
+}
@@ -0,0 +1,144 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	blockUserActionName   = "block_user"
+	unblockUserActionName = "unblock_user"
+	userIdActionArgument  = "resource_id"
+)
+
+var (
+	_ connectorbuilder.ResourceDeleter        = (*userBuilder)(nil)
+	_ connectorbuilder.ResourceActionProvider = (*userBuilder)(nil)
+)
+
+// Delete permanently removes the user from Auth0.
+func (b *userBuilder) Delete(
+	ctx context.Context,
+	resourceId *v2.ResourceId,
+) (
+	annotations.Annotations,
+	error,
+) {
+	l := ctxzap.Extract(ctx)
+	var outputAnnotations annotations.Annotations
+
+	rateLimitData, err := b.client.DeleteUser(ctx, resourceId.Resource)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		l.Error(
+			"baton-auth0: failed to delete user",
+			zap.String("user_id", resourceId.Resource),
+			zap.Error(err),
+		)
+		return outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return outputAnnotations, nil
+}
+
+// ResourceActions registers the actions that block and unblock a user, which flip
+// the status userResource derives from User.Blocked.
+func (b *userBuilder) ResourceActions(ctx context.Context, registry actions.ActionRegistry) error {
+	err := registry.Register(
+		ctx,
+		userActionSchema(
+			blockUserActionName,
+			"Block user",
+			"Block the Auth0 user so they can no longer log in.",
+			v2.ActionType_ACTION_TYPE_ACCOUNT_DISABLE,
+		),
+		b.setBlockedHandler(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	return registry.Register(
+		ctx,
+		userActionSchema(
+			unblockUserActionName,
+			"Unblock user",
+			"Unblock the Auth0 user so they can log in again.",
+			v2.ActionType_ACTION_TYPE_ACCOUNT_ENABLE,
+		),
+		b.setBlockedHandler(false),
+	)
+}
+
+func (b *userBuilder) setBlockedHandler(blocked bool) actions.ActionHandler {
+	return func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, annotations.Annotations, error) {
+		var outputAnnotations annotations.Annotations
+
+		userId, ok := actions.GetResourceIDArg(args, userIdActionArgument)
+		if !ok || userId.Resource == "" {
+			return nil, nil, fmt.Errorf("baton-auth0: missing %s argument", userIdActionArgument)
+		}
+		if userId.ResourceType != userResourceType.Id {
+			return nil, nil, fmt.Errorf("baton-auth0: %s is not a user", userId.ResourceType)
+		}
+
+		rateLimitData, err := b.client.SetUserBlocked(ctx, userId.Resource, blocked)
+		if err != nil {
+			if rateLimitData != nil {
+				outputAnnotations.WithRateLimiting(rateLimitData)
+			}
+			return nil, outputAnnotations, wrapError(err)
+		}
+		outputAnnotations.WithRateLimiting(rateLimitData)
+
+		return actions.NewReturnValues(true), outputAnnotations, nil
+	}
+}
+
+func userActionSchema(
+	name string,
+	displayName string,
+	description string,
+	actionType v2.ActionType,
+) *v2.BatonActionSchema {
+	return &v2.BatonActionSchema{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		ActionType:  []v2.ActionType{actionType},
+		Arguments: []*config.Field{
+			{
+				Name:        userIdActionArgument,
+				DisplayName: "User",
+				Description: "The user to update.",
+				IsRequired:  true,
+				Field: &config.Field_ResourceIdField{
+					ResourceIdField: &config.ResourceIdField{
+						Rules: &config.ResourceIDRules{
+							AllowedResourceTypeIds: []string{userResourceType.Id},
+						},
+					},
+				},
+			},
+		},
+		ReturnTypes: []*config.Field{
+			{
+				Name:        "success",
+				DisplayName: "Success",
+				Field:       &config.Field_BoolField{BoolField: &config.BoolField{}},
+			},
+		},
+	}
+}
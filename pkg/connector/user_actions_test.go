@@ -0,0 +1,91 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/actions"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// actionRegistry keeps the handlers registered by a builder by action name.
+type actionRegistry struct {
+	schemas  map[string]*v2.BatonActionSchema
+	handlers map[string]actions.ActionHandler
+}
+
+func (r *actionRegistry) Register(_ context.Context, schema *v2.BatonActionSchema, handler actions.ActionHandler) error {
+	r.schemas[schema.Name] = schema
+	r.handlers[schema.Name] = handler
+	return nil
+}
+
+func (r *actionRegistry) RegisterAction(ctx context.Context, _ string, schema *v2.BatonActionSchema, handler actions.ActionHandler) error {
+	return r.Register(ctx, schema, handler)
+}
+
+func userActionArgs(t *testing.T, resourceType string, userId string) *structpb.Struct {
+	args, err := structpb.NewStruct(map[string]any{
+		userIdActionArgument: map[string]any{
+			"resource_type_id": resourceType,
+			"resource_id":      userId,
+		},
+	})
+	require.NoError(t, err)
+	return args
+}
+
+func TestUserDelete(t *testing.T) {
+	ctx := context.Background()
+
+	tenant, c0 := newUserAdminServer(t)
+	tenant.users = []client2.User{{UserId: "auth0|1"}, {UserId: "auth0|2"}}
+	ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+	_, err := ub.Delete(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|1"})
+	require.NoError(t, err)
+	require.Equal(t, []client2.User{{UserId: "auth0|2"}}, tenant.users)
+}
+
+func TestUserBlockActions(t *testing.T) {
+	ctx := context.Background()
+
+	tenant, c0 := newUserAdminServer(t)
+	tenant.users = []client2.User{{UserId: "auth0|1"}}
+	ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+	registry := &actionRegistry{
+		schemas:  make(map[string]*v2.BatonActionSchema),
+		handlers: make(map[string]actions.ActionHandler),
+	}
+	require.NoError(t, ub.ResourceActions(ctx, registry))
+	require.Equal(t, []v2.ActionType{v2.ActionType_ACTION_TYPE_ACCOUNT_DISABLE}, registry.schemas[blockUserActionName].ActionType)
+	require.Equal(t, []v2.ActionType{v2.ActionType_ACTION_TYPE_ACCOUNT_ENABLE}, registry.schemas[unblockUserActionName].ActionType)
+
+	t.Run("block", func(t *testing.T) {
+		result, _, err := registry.handlers[blockUserActionName](ctx, userActionArgs(t, userResourceType.Id, "auth0|1"))
+		require.NoError(t, err)
+		require.True(t, result.Fields["success"].GetBoolValue())
+		require.True(t, tenant.user("auth0|1").Blocked)
+	})
+
+	t.Run("unblock", func(t *testing.T) {
+		result, _, err := registry.handlers[unblockUserActionName](ctx, userActionArgs(t, userResourceType.Id, "auth0|1"))
+		require.NoError(t, err)
+		require.True(t, result.Fields["success"].GetBoolValue())
+		require.False(t, tenant.user("auth0|1").Blocked)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		_, _, err := registry.handlers[blockUserActionName](ctx, &structpb.Struct{})
+		require.Error(t, err)
+	})
+
+	t.Run("not a user", func(t *testing.T) {
+		_, _, err := registry.handlers[blockUserActionName](ctx, userActionArgs(t, roleResourceType.Id, "rol_1"))
+		require.Error(t, err)
+	})
+}
@@ -1,48 +1,180 @@
 package connector
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	client2 "github.com/conductorone/baton-auth0/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/crypto"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	sdkGrant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-var _ connectorbuilder.ResourceSyncer = (*userBuilder)(nil)
+const (
+	defaultAccountConnection = "Username-Password-Authentication"
+	// Length of the throwaway password set on invited users, who choose their own
+	// password through a change-password ticket and never learn this one.
+	invitationPasswordLength = 32
+	databaseIdentityProvider = "auth0"
+)
+
+var (
+	_ connectorbuilder.AccountManager         = (*userBuilder)(nil)
+	_ connectorbuilder.CredentialManager      = (*userBuilder)(nil)
+	_ connectorbuilder.ResourceTargetedSyncer = (*userBuilder)(nil)
+)
 
 type userBuilder struct {
-	client *client2.Client
+	client          *client2.Client
+	syncPermissions bool
+	// exportUsers lists users from a users export job instead of the user search.
+	exportUsers bool
+	filter      *resourceFilter
+	mapping     userAttributeMapping
 }
 
 func (b *userBuilder) ResourceType(_ context.Context) *v2.ResourceType {
 	return userResourceType
 }
 
+// userAttributeMapping holds the dot separated app_metadata paths of the user
+// attributes Auth0 has no field for. Empty paths aren't mapped.
+type userAttributeMapping struct {
+	employeeIdPath string
+	managerPath    string
+	departmentPath string
+}
+
+// appMetadataValue returns the string, number or boolean at a dot separated path
+// of the user's app_metadata, or an empty string if there is none.
+func appMetadataValue(user client2.User, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	var value interface{} = user.AppMetadata
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return ""
+}
+
+// userLogin returns the identifier the user logs in with: the email, or the
+// username or phone number of users without one. The username is an alias of
+// the email. Users with none of them, like some social and enterprise users,
+// log in with their user ID.
+func userLogin(user client2.User) (string, []string) {
+	switch {
+	case user.Email != "" && user.Username != "":
+		return user.Email, []string{user.Username}
+	case user.Email != "":
+		return user.Email, nil
+	case user.Username != "":
+		return user.Username, nil
+	case user.PhoneNumber != "":
+		return user.PhoneNumber, nil
+	}
+	return user.UserId, nil
+}
+
 // Create a new connector resource for an Auth0 user.
-func userResource(user client2.User, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
-	firstName, lastName := resourceSdk.SplitFullName(user.Name)
+func userResource(
+	user client2.User,
+	parentResourceID *v2.ResourceId,
+	mapping userAttributeMapping,
+) (*v2.Resource, error) {
+	firstName, lastName := user.GivenName, user.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName = resourceSdk.SplitFullName(user.Name)
+	}
+
+	connections := make([]interface{}, 0, len(user.Identities))
+	for _, identity := range user.Identities {
+		connections = append(connections, identity.Connection)
+	}
+	multifactor := make([]interface{}, 0, len(user.Multifactor))
+	for _, provider := range user.Multifactor {
+		multifactor = append(multifactor, provider)
+	}
 
 	profile := map[string]interface{}{
-		"id":         user.UserId,
-		"email":      user.Email,
-		"first_name": firstName,
-		"last_name":  lastName,
-		"nickname":   user.Nickname,
+		"id":             user.UserId,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+		"first_name":     firstName,
+		"last_name":      lastName,
+		"nickname":       user.Nickname,
+		"username":       user.Username,
+		"phone_number":   user.PhoneNumber,
+		"logins_count":   user.LoginsCount,
+		"last_ip":        user.LastIP,
+		"multifactor":    multifactor,
+		"connections":    connections,
+	}
+	if user.LastPasswordReset != nil {
+		profile["last_password_reset"] = user.LastPasswordReset.Format(time.RFC3339)
 	}
 
+	// The primary identity is always the first one. Users are listed at the top
+	// level, and every connection they have an identity in is a connection grant.
+	if len(user.Identities) > 0 {
+		profile["connection"] = user.Identities[0].Connection
+	}
+
+	login, loginAliases := userLogin(user)
 	userTraitOptions := []resourceSdk.UserTraitOption{
-		resourceSdk.WithEmail(user.Email, true),
-		resourceSdk.WithUserLogin(user.Email),
+		resourceSdk.WithUserLogin(login, loginAliases...),
+		resourceSdk.WithStructuredName(&v2.UserTrait_StructuredName{
+			GivenName:  firstName,
+			FamilyName: lastName,
+		}),
+		resourceSdk.WithMFAStatus(&v2.UserTrait_MFAStatus{MfaEnabled: len(user.Multifactor) > 0}),
+	}
+	// Unverified emails aren't known to belong to the user, so they aren't primary.
+	if user.Email != "" {
+		userTraitOptions = append(userTraitOptions, resourceSdk.WithEmail(user.Email, user.EmailVerified))
 	}
 	if user.LastLogin != nil {
 		userTraitOptions = append(userTraitOptions, resourceSdk.WithLastLogin(*user.LastLogin))
 	}
+	if employeeId := appMetadataValue(user, mapping.employeeIdPath); employeeId != "" {
+		userTraitOptions = append(userTraitOptions, resourceSdk.WithEmployeeID(employeeId))
+		profile["employee_id"] = employeeId
+	}
+	// The user trait has no manager or department, so they are only in the profile.
+	if manager := appMetadataValue(user, mapping.managerPath); manager != "" {
+		profile["manager"] = manager
+	}
+	if department := appMetadataValue(user, mapping.departmentPath); department != "" {
+		profile["department"] = department
+	}
 
 	status := v2.Status_RESOURCE_STATUS_ENABLED
 	if user.Blocked {
@@ -50,7 +182,7 @@ func userResource(user client2.User, parentResourceID *v2.ResourceId) (*v2.Resou
 	}
 
 	return resourceSdk.NewUserResource(
-		user.Nickname,
+		cmp.Or(user.Name, user.Nickname, login, user.UserId),
 		userResourceType,
 		user.UserId,
 		userTraitOptions,
@@ -72,64 +204,136 @@ func (b *userBuilder) List(
 	annotations.Annotations,
 	error,
 ) {
-	l := ctxzap.Extract(ctx)
+	// Users are listed before any grants, so the first page starts a sync.
+	if pToken == nil || pToken.Token == "" {
+		b.filter.resetPrincipals()
+	}
+
+	if b.exportUsers {
+		return b.listExportedUsers(ctx, parentResourceID, pToken)
+	}
 
 	outputResources := make([]*v2.Resource, 0)
 	var outputAnnotations annotations.Annotations
 
-	page, limit, since, until, newestUserCreationDate, err := client2.ParseUserPaginationToken(pToken)
+	window, limit, err := client2.ParseUserPaginationToken(pToken)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	users, total, rateLimitData, err := b.client.GetUsers(ctx, limit, page, since, until)
+	users, nextToken, rateLimitData, err := searchUsers(ctx, b.client, b.filter, window, limit)
 	if err != nil {
 		if rateLimitData != nil {
 			outputAnnotations.WithRateLimiting(rateLimitData)
 		}
-		return nil, "", outputAnnotations, err
+		return nil, "", outputAnnotations, wrapError(err)
 	}
 	outputAnnotations.WithRateLimiting(rateLimitData)
 
-	if len(users) == 0 {
-		return outputResources, "", outputAnnotations, nil
-	}
-
-	var newestCreatedAt time.Time
-	if newestUserCreationDate != nil {
-		newestCreatedAt = *newestUserCreationDate
-	}
-
 	for _, user := range users {
-		if user.CreatedAt.UTC().After(newestCreatedAt) {
-			newestCreatedAt = user.CreatedAt.UTC()
-		}
+		b.filter.allowPrincipal(user.UserId)
 
-		userResource0, err := userResource(user, parentResourceID)
+		userResource0, err := userResource(user, parentResourceID, b.mapping)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		outputResources = append(outputResources, userResource0)
 	}
 
+	return outputResources, nextToken, outputAnnotations, nil
+}
+
+// searchUsers returns a page of the users in a window of the user search, without
+// the users the previous window already returned or the connection filters
+// exclude, and the token of the next page. It leaves the filter untouched, so that
+// change detection can search users outside of a sync.
+func searchUsers(
+	ctx context.Context,
+	client *client2.Client,
+	filter *resourceFilter,
+	window client2.UserPagination,
+	limit int,
+) (
+	[]client2.User,
+	string,
+	*v2.RateLimitDescription,
+	error,
+) {
+	l := ctxzap.Extract(ctx)
+
+	users, total, rateLimitData, err := client.GetUsers(ctx, limit, window.Page, window.Query(), window.Sort())
+	if err != nil {
+		return nil, "", rateLimitData, err
+	}
+
+	found := make([]client2.User, 0, len(users))
+	for _, user := range users {
+		// Windows overlap on the users created at their boundary.
+		if window.Skip(user.UserId) || !filter.allowsUser(user) {
+			continue
+		}
+		found = append(found, user)
+	}
+
 	// Auth0's User Search API enforces a hard cap of 1,000 results, even when paginating.
 	// Requesting beyond this limit returns a 400 error.
 	// See https://auth0.com/docs/manage-users/user-search/view-search-results-by-page#limitation.
-	if total > client2.Auth0UserSearchMaxResults {
+	if total > client2.Auth0UserSearchMaxResults && (window.Page+1)*limit >= client2.Auth0UserSearchMaxResults {
 		l.Debug(
 			"Auth0 user search exceeds 1000-result API limit; using date-range windowing to fetch remaining users.",
 			zap.Int("total_users", total),
 			zap.Int("api_limit", client2.Auth0UserSearchMaxResults),
 		)
-		total = client2.Auth0UserSearchMaxResults
 	}
 
-	nextToken, err := client2.GetNextUsersToken(page, limit, total, since, &newestCreatedAt)
+	nextToken, err := client2.GetNextUsersToken(window, limit, total, users)
 	if err != nil {
-		return nil, "", nil, err
+		return nil, "", rateLimitData, err
 	}
 
-	return outputResources, nextToken, outputAnnotations, nil
+	return found, nextToken, rateLimitData, nil
+}
+
+// Get returns a single user, which lets targeted syncs re-fetch only the users
+// that changed.
+func (b *userBuilder) Get(
+	ctx context.Context,
+	resourceId *v2.ResourceId,
+	parentResourceId *v2.ResourceId,
+) (
+	*v2.Resource,
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+
+	user, rateLimitData, err := b.client.GetUser(ctx, resourceId.Resource)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	allowed, rateLimitData, err := b.filter.allowsFetchedUser(ctx, b.client, *user)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+	if !allowed {
+		return nil, outputAnnotations, status.Errorf(codes.NotFound, "baton-auth0: user %s is filtered out", user.UserId)
+	}
+
+	resource, err := userResource(*user, parentResourceId, b.mapping)
+	if err != nil {
+		return nil, outputAnnotations, err
+	}
+
+	return resource, outputAnnotations, nil
 }
 
 // Entitlements always returns an empty slice for users.
@@ -146,20 +350,361 @@ func (b *userBuilder) Entitlements(
 	return nil, "", nil, nil
 }
 
-// Grants always returns an empty slice for users since they don't have any entitlements.
+// Grants returns the connections the user authenticates through and the scopes
+// assigned directly to the user, bypassing roles. Users don't have any entitlements
+// of their own, so these grants are on connection and scope entitlements.
 func (b *userBuilder) Grants(
-	_ context.Context,
-	_ *v2.Resource,
-	_ *pagination.Token,
+	ctx context.Context,
+	resource *v2.Resource,
+	pToken *pagination.Token,
 ) (
 	[]*v2.Grant,
 	string,
 	annotations.Annotations,
 	error,
 ) {
-	return nil, "", nil, nil
+	var outputAnnotations annotations.Annotations
+	page, limit, _, err := client2.ParsePaginationToken(pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var grants []*v2.Grant
+	if page == 0 {
+		grants = connectionUserGrants(resource, b.filter)
+	}
+
+	if !b.syncPermissions {
+		return grants, "", nil, nil
+	}
+
+	permissions, total, rateLimitData, err := b.client.GetUserPermissions(
+		ctx,
+		resource.Id.Resource,
+		limit,
+		page,
+	)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, "", outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	if len(permissions) == 0 {
+		return grants, "", outputAnnotations, nil
+	}
+
+	for _, permission := range permissions {
+		// Same as formatScopeId function in scope.go
+		scopeId := fmt.Sprintf("%s:%s", permission.ResourceServerIdentifier, permission.PermissionName)
+
+		scopeResourceId, err := resourceSdk.NewResourceID(scopeResourceType, scopeId)
+		if err != nil {
+			return nil, "", outputAnnotations, err
+		}
+		nextGrant := sdkGrant.NewGrant(
+			&v2.Resource{Id: scopeResourceId},
+			scopeEntitlementName,
+			resource.Id,
+		)
+		grants = append(grants, nextGrant)
+	}
+
+	nextToken := client2.GetNextToken(page, limit, total)
+	return grants, nextToken, outputAnnotations, nil
 }
 
-func newUserBuilder(client *client2.Client) *userBuilder {
-	return &userBuilder{client: client}
+func (b *userBuilder) CreateAccountCapabilityDetails(
+	_ context.Context,
+) (
+	*v2.CredentialDetailsAccountProvisioning,
+	annotations.Annotations,
+	error,
+) {
+	return &v2.CredentialDetailsAccountProvisioning{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_NO_PASSWORD,
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_NO_PASSWORD,
+	}, nil, nil
+}
+
+// CreateAccount creates a user in an Auth0 connection. With a random password the
+// generated password is returned to be encrypted by the SDK. Without a password the
+// user is invited instead: it gets a throwaway password and a change-password
+// ticket is returned so the user can choose their own.
+func (b *userBuilder) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	credentialOptions *v2.LocalCredentialOptions,
+) (
+	connectorbuilder.CreateAccountResponse,
+	[]*v2.PlaintextData,
+	annotations.Annotations,
+	error,
+) {
+	l := ctxzap.Extract(ctx)
+	var outputAnnotations annotations.Annotations
+
+	request, err := createUserRequest(accountInfo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	invite := credentialOptions.GetNoPassword() != nil
+	if invite {
+		request.Password, err = crypto.GenerateRandomPassword(
+			&v2.LocalCredentialOptions_RandomPassword{Length: invitationPasswordLength},
+		)
+	} else {
+		request.Password, err = crypto.GeneratePassword(ctx, credentialOptions)
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-auth0: failed to generate password: %w", err)
+	}
+
+	user, rateLimitData, err := b.client.CreateUser(ctx, *request)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		if status.Code(err) == codes.AlreadyExists {
+			return b.existingAccount(ctx, request, outputAnnotations)
+		}
+		return nil, nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	resource, err := userResource(*user, nil, b.mapping)
+	if err != nil {
+		return nil, nil, outputAnnotations, err
+	}
+
+	if !invite {
+		return &v2.CreateAccountResponse_SuccessResult{
+				Resource:              resource,
+				IsCreateAccountResult: true,
+			},
+			[]*v2.PlaintextData{
+				{
+					Name:        "password",
+					Description: "The password of the new Auth0 user",
+					Bytes:       []byte(request.Password),
+				},
+			},
+			outputAnnotations,
+			nil
+	}
+
+	ticket, rateLimitData, err := b.client.CreatePasswordChangeTicket(ctx, user.UserId)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		l.Error(
+			"baton-auth0: user created but the password change ticket could not be issued",
+			zap.String("user_id", user.UserId),
+			zap.Error(err),
+		)
+		return nil, nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return &v2.CreateAccountResponse_ActionRequiredResult{
+			Resource:              resource,
+			Message:               "The user must set a password using the password change ticket",
+			IsCreateAccountResult: true,
+		},
+		[]*v2.PlaintextData{
+			{
+				Name:        "password_change_ticket",
+				Description: "The URL the new Auth0 user follows to set a password",
+				Bytes:       []byte(ticket),
+			},
+		},
+		outputAnnotations,
+		nil
+}
+
+// existingAccount looks up the user that already exists in the requested connection
+// with the requested email.
+func (b *userBuilder) existingAccount(
+	ctx context.Context,
+	request *client2.CreateUserRequest,
+	outputAnnotations annotations.Annotations,
+) (
+	connectorbuilder.CreateAccountResponse,
+	[]*v2.PlaintextData,
+	annotations.Annotations,
+	error,
+) {
+	users, rateLimitData, err := b.client.GetUsersByEmail(ctx, request.Email)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	for _, user := range users {
+		for _, identity := range user.Identities {
+			if identity.Connection != request.Connection {
+				continue
+			}
+			resource, err := userResource(user, nil, b.mapping)
+			if err != nil {
+				return nil, nil, outputAnnotations, err
+			}
+			return &v2.CreateAccountResponse_AlreadyExistsResult{
+				Resource:              resource,
+				IsCreateAccountResult: true,
+			}, nil, outputAnnotations, nil
+		}
+	}
+
+	return nil, nil, outputAnnotations, fmt.Errorf(
+		"baton-auth0: user %s already exists in connection %s but could not be found",
+		request.Email,
+		request.Connection,
+	)
+}
+
+func (b *userBuilder) RotateCapabilityDetails(
+	_ context.Context,
+) (
+	*v2.CredentialDetailsCredentialRotation,
+	annotations.Annotations,
+	error,
+) {
+	return &v2.CredentialDetailsCredentialRotation{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+	}, nil, nil
+}
+
+// Rotate sets a new random password on a database user. The password satisfies the
+// password policy of the user's database connection.
+func (b *userBuilder) Rotate(
+	ctx context.Context,
+	resourceId *v2.ResourceId,
+	credentialOptions *v2.LocalCredentialOptions,
+) (
+	[]*v2.PlaintextData,
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+
+	if credentialOptions.GetRandomPassword() == nil {
+		return nil, nil, fmt.Errorf("baton-auth0: only random passwords are supported when rotating credentials")
+	}
+
+	user, rateLimitData, err := b.client.GetUser(ctx, resourceId.Resource)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	// Only database connections hold passwords, and their identities always have the
+	// auth0 provider.
+	var connectionName string
+	for _, identity := range user.Identities {
+		if identity.Provider == databaseIdentityProvider {
+			connectionName = identity.Connection
+			break
+		}
+	}
+	if connectionName == "" {
+		return nil, outputAnnotations, fmt.Errorf(
+			"baton-auth0: user %s has no database connection identity, so it has no password to rotate",
+			resourceId.Resource,
+		)
+	}
+
+	connection, rateLimitData, err := b.client.GetConnectionByName(ctx, connectionName)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	password, err := newPasswordPolicy(connection.Options).generatePassword(credentialOptions.GetRandomPassword())
+	if err != nil {
+		return nil, outputAnnotations, err
+	}
+
+	rateLimitData, err = b.client.UpdateUserPassword(ctx, resourceId.Resource, connectionName, password)
+	if err != nil {
+		if rateLimitData != nil {
+			outputAnnotations.WithRateLimiting(rateLimitData)
+		}
+		return nil, outputAnnotations, wrapError(err)
+	}
+	outputAnnotations.WithRateLimiting(rateLimitData)
+
+	return []*v2.PlaintextData{
+		{
+			Name:        "password",
+			Description: "The new password of the Auth0 user",
+			Bytes:       []byte(password),
+		},
+	}, outputAnnotations, nil
+}
+
+func createUserRequest(accountInfo *v2.AccountInfo) (*client2.CreateUserRequest, error) {
+	profile := accountInfo.GetProfile()
+
+	email, ok := resourceSdk.GetProfileStringValue(profile, "email")
+	if !ok || email == "" {
+		for _, accountEmail := range accountInfo.GetEmails() {
+			if email == "" || accountEmail.GetIsPrimary() {
+				email = accountEmail.GetAddress()
+			}
+		}
+	}
+	if email == "" {
+		return nil, errors.New("baton-auth0: email is required to create a user")
+	}
+
+	connection, ok := resourceSdk.GetProfileStringValue(profile, "connection")
+	if !ok || connection == "" {
+		connection = defaultAccountConnection
+	}
+
+	name, _ := resourceSdk.GetProfileStringValue(profile, "name")
+	username, _ := resourceSdk.GetProfileStringValue(profile, "username")
+
+	return &client2.CreateUserRequest{
+		Connection: connection,
+		Email:      email,
+		Name:       name,
+		Username:   username,
+	}, nil
+}
+
+func newUserBuilder(
+	client *client2.Client,
+	syncPermissions bool,
+	exportUsers bool,
+	filter *resourceFilter,
+	mapping userAttributeMapping,
+) *userBuilder {
+	return &userBuilder{
+		client:          client,
+		syncPermissions: syncPermissions,
+		exportUsers:     exportUsers,
+		filter:          filter,
+		mapping:         mapping,
+	}
 }
@@ -0,0 +1,200 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+// userSyncStrategyExport is the user sync strategy listing users from a users
+// export job.
+const userSyncStrategyExport = "export"
+
+// usersExportMaxWait is how long a page waits for the users export job to
+// complete. Pages return no users until it does, so that the sync keeps its
+// progress while the job runs.
+var usersExportMaxWait = time.Minute
+
+// usersExportPath is where the result of a users export job of a tenant is
+// downloaded, so that each page reads on from where the previous one stopped.
+func usersExportPath(tenant string, jobId string) string {
+	return filepath.Join(
+		os.TempDir(),
+		fmt.Sprintf("baton-auth0-users-export-%s-%s.ndjson", filepath.Base(tenant), filepath.Base(jobId)),
+	)
+}
+
+// removeUsersExports removes the downloads of the previous users export jobs of a
+// tenant, which a sync that didn't finish leaves behind.
+func removeUsersExports(tenant string) {
+	paths, _ := filepath.Glob(usersExportPath(tenant, "*"))
+	for _, path := range paths {
+		_ = os.Remove(path)
+	}
+}
+
+// listExportedUsers lists the users from a users export job instead of the user
+// search, which is faster for large tenants and doesn't lag behind like the
+// search index.
+func (b *userBuilder) listExportedUsers(
+	ctx context.Context,
+	parentResourceID *v2.ResourceId,
+	pToken *pagination.Token,
+) (
+	[]*v2.Resource,
+	string,
+	annotations.Annotations,
+	error,
+) {
+	var outputAnnotations annotations.Annotations
+
+	position, limit, err := client2.ParseUsersExportToken(pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if position.JobId == "" {
+		job, rateLimitData, err := b.client.CreateUsersExport(ctx)
+		if err != nil {
+			if rateLimitData != nil {
+				outputAnnotations.WithRateLimiting(rateLimitData)
+			}
+			return nil, "", outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to start users export: %w", err))
+		}
+		outputAnnotations.WithRateLimiting(rateLimitData)
+		removeUsersExports(b.client.BaseUrl.Hostname())
+		position.JobId = job.Id
+	}
+
+	path := usersExportPath(b.client.BaseUrl.Hostname(), position.JobId)
+	_, err = os.Stat(path)
+	// A download left by a previous page is read from the offset it reached. A new
+	// download, when the sync resumed elsewhere, is read from the line it reached.
+	resumed := err == nil
+	if errors.Is(err, os.ErrNotExist) {
+		job, rateLimitData, err := b.client.WaitForJob(ctx, position.JobId, usersExportMaxWait)
+		if err != nil {
+			if rateLimitData != nil {
+				outputAnnotations.WithRateLimiting(rateLimitData)
+			}
+			return nil, "", outputAnnotations, wrapError(fmt.Errorf("baton-auth0: failed to get users export: %w", err))
+		}
+		outputAnnotations.WithRateLimiting(rateLimitData)
+
+		switch job.Status {
+		case client2.JobStatusCompleted:
+		case client2.JobStatusFailed:
+			return nil, "", outputAnnotations, fmt.Errorf("baton-auth0: users export %s failed", job.Id)
+		default:
+			nextToken, err := client2.GetNextUsersExportToken(position)
+			if err != nil {
+				return nil, "", nil, err
+			}
+			return nil, nextToken, outputAnnotations, nil
+		}
+
+		err = b.client.DownloadUsersExport(ctx, job.Location, path)
+		if err != nil {
+			return nil, "", outputAnnotations, err
+		}
+	} else if err != nil {
+		return nil, "", outputAnnotations, err
+	}
+
+	outputResources, done, err := b.readUsersExport(path, resumed, &position, limit, parentResourceID)
+	if err != nil || done {
+		// The download is only kept for the next page.
+		_ = os.Remove(path)
+		if err != nil {
+			return nil, "", outputAnnotations, err
+		}
+		return outputResources, "", outputAnnotations, nil
+	}
+
+	nextToken, err := client2.GetNextUsersExportToken(position)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return outputResources, nextToken, outputAnnotations, nil
+}
+
+// readUsersExport reads the next page of users from a downloaded users export,
+// advancing position, and reports whether the export has been read to its end.
+func (b *userBuilder) readUsersExport(
+	path string,
+	resumed bool,
+	position *client2.UsersExportPagination,
+	limit int,
+	parentResourceID *v2.ResourceId,
+) ([]*v2.Resource, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if resumed {
+		_, err = file.Seek(position.Offset, io.SeekStart)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		position.Offset = 0
+		for range position.Line {
+			line, err := reader.ReadBytes('\n')
+			position.Offset += int64(len(line))
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	outputResources := make([]*v2.Resource, 0, limit)
+	for len(outputResources) < limit {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, err
+		}
+		position.Offset += int64(len(line))
+
+		if len(line) > 0 {
+			position.Line++
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var user client2.User
+			err := json.Unmarshal(line, &user)
+			if err != nil {
+				return nil, false, fmt.Errorf("baton-auth0: invalid user on line %d of users export: %w", position.Line, err)
+			}
+			// Exports can't be searched, so only the connection filters apply.
+			if b.filter.allowsUser(user) {
+				b.filter.allowPrincipal(user.UserId)
+				userResource0, err := userResource(user, parentResourceID, b.mapping)
+				if err != nil {
+					return nil, false, err
+				}
+				outputResources = append(outputResources, userResource0)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return outputResources, true, nil
+		}
+	}
+
+	return outputResources, false, nil
+}
@@ -0,0 +1,197 @@
+package connector
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	client2 "github.com/conductorone/baton-auth0/pkg/client"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+// usersExportServer serves users export jobs like Auth0 does: jobs are pending
+// until completed, and their result is a gzipped NDJSON file.
+type usersExportServer struct {
+	mu        sync.Mutex
+	url       string
+	lines     []string
+	completed bool
+	jobs      int
+	downloads int
+}
+
+func (s *usersExportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/jobs/users-exports":
+		s.jobs++
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(client2.Job{Id: s.jobId(), Type: "users_export", Status: client2.JobStatusPending})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/jobs/"+s.jobId():
+		job := client2.Job{Id: s.jobId(), Type: "users_export", Status: client2.JobStatusPending}
+		if s.completed {
+			job.Status = client2.JobStatusCompleted
+			job.Location = s.url + "/download/" + s.jobId()
+		}
+		_ = json.NewEncoder(w).Encode(job)
+	case r.Method == http.MethodGet && r.URL.Path == "/download/"+s.jobId():
+		s.downloads++
+		w.Header().Set("Content-Type", "application/gzip")
+		writer := gzip.NewWriter(w)
+		for _, line := range s.lines {
+			_, _ = writer.Write([]byte(line + "\n"))
+		}
+		_ = writer.Close()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *usersExportServer) jobId() string {
+	return fmt.Sprintf("job_%d", s.jobs)
+}
+
+func newUsersExportServer(t *testing.T, count int) (*usersExportServer, *userBuilder) {
+	// Downloads go to the temporary directory.
+	t.Setenv("TMPDIR", t.TempDir())
+	maxWait := usersExportMaxWait
+	usersExportMaxWait = 0
+	t.Cleanup(func() { usersExportMaxWait = maxWait })
+
+	tenant := &usersExportServer{completed: true}
+	for i := range count {
+		line, err := json.Marshal(client2.User{
+			UserId:     fmt.Sprintf("auth0|%d", i),
+			CreatedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Identities: []client2.UserIdentities{{Connection: "employees", Provider: "auth0"}},
+		})
+		require.NoError(t, err)
+		tenant.lines = append(tenant.lines, string(line))
+	}
+	server := httptest.NewServer(tenant)
+	t.Cleanup(server.Close)
+	tenant.url = server.URL
+
+	c0, err := client2.New(context.Background(), server.URL, "mock", "token")
+	require.NoError(t, err)
+	return tenant, newUserBuilder(c0, false, true, &resourceFilter{}, userAttributeMapping{})
+}
+
+// usersExportDownloads returns the users export downloads left in the temporary
+// directory.
+func usersExportDownloads(t *testing.T) []string {
+	paths, err := filepath.Glob(filepath.Join(os.TempDir(), "baton-auth0-users-export-*"))
+	require.NoError(t, err)
+	return paths
+}
+
+func TestUsersListExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pending job", func(t *testing.T) {
+		tenant, ub := newUsersExportServer(t, 5)
+		tenant.completed = false
+
+		resources, nextToken, _, err := ub.List(ctx, nil, &pagination.Token{Size: 2})
+		require.NoError(t, err)
+		require.Empty(t, resources)
+		require.NotEmpty(t, nextToken)
+		require.Empty(t, usersExportDownloads(t))
+
+		// Pages keep polling the same job until it completes.
+		resources, nextToken, _, err = ub.List(ctx, nil, &pagination.Token{Size: 2, Token: nextToken})
+		require.NoError(t, err)
+		require.Empty(t, resources)
+
+		tenant.completed = true
+		resources, _, _, err = ub.List(ctx, nil, &pagination.Token{Size: 2, Token: nextToken})
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+		require.Equal(t, 1, tenant.jobs)
+	})
+
+	t.Run("resume by offset", func(t *testing.T) {
+		tenant, ub := newUsersExportServer(t, 5)
+
+		var listed []string
+		pToken := &pagination.Token{Size: 2}
+		for range maxPages {
+			resources, nextToken, _, err := ub.List(ctx, nil, pToken)
+			require.NoError(t, err)
+			for _, resource := range resources {
+				listed = append(listed, resource.Id.Resource)
+			}
+			if nextToken == "" {
+				break
+			}
+			// The download is kept for the next page.
+			require.Len(t, usersExportDownloads(t), 1)
+			pToken = &pagination.Token{Size: 2, Token: nextToken}
+		}
+
+		require.Equal(t, []string{"auth0|0", "auth0|1", "auth0|2", "auth0|3", "auth0|4"}, listed)
+		require.Equal(t, 1, tenant.downloads)
+		require.Empty(t, usersExportDownloads(t))
+	})
+
+	t.Run("resume by line after a new download", func(t *testing.T) {
+		tenant, ub := newUsersExportServer(t, 5)
+
+		resources, nextToken, _, err := ub.List(ctx, nil, &pagination.Token{Size: 2})
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+
+		// The sync resumes where the download is gone.
+		for _, path := range usersExportDownloads(t) {
+			require.NoError(t, os.Remove(path))
+		}
+
+		resources, _, _, err = ub.List(ctx, nil, &pagination.Token{Size: 2, Token: nextToken})
+		require.NoError(t, err)
+		require.Equal(t, "auth0|2", resources[0].Id.Resource)
+		require.Equal(t, "auth0|3", resources[1].Id.Resource)
+		require.Equal(t, 2, tenant.downloads)
+	})
+
+	t.Run("invalid export", func(t *testing.T) {
+		tenant, ub := newUsersExportServer(t, 2)
+		tenant.lines = append(tenant.lines, "{")
+
+		_, nextToken, _, err := ub.List(ctx, nil, &pagination.Token{Size: 2})
+		require.NoError(t, err)
+
+		_, _, _, err = ub.List(ctx, nil, &pagination.Token{Size: 2, Token: nextToken})
+		require.Error(t, err)
+		require.Empty(t, usersExportDownloads(t))
+	})
+
+	t.Run("new job", func(t *testing.T) {
+		tenant, ub := newUsersExportServer(t, 5)
+
+		_, _, _, err := ub.List(ctx, nil, &pagination.Token{Size: 2})
+		require.NoError(t, err)
+		require.Len(t, usersExportDownloads(t), 1)
+
+		// A sync that starts over replaces the download of the previous job.
+		_, _, _, err = ub.List(ctx, nil, &pagination.Token{Size: 2})
+		require.NoError(t, err)
+		require.Equal(t, 2, tenant.jobs)
+		downloads := usersExportDownloads(t)
+		require.Len(t, downloads, 1)
+		require.Contains(t, downloads[0], "job_2")
+	})
+}
@@ -3,9 +3,15 @@ package connector
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,7 +19,9 @@ import (
 	"github.com/conductorone/baton-auth0/test"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func TestUsersListMaxResultsCap(t *testing.T) {
@@ -53,7 +61,7 @@ func TestUsersListMaxResultsCap(t *testing.T) {
 		c0, err := client2.New(ctx, server.URL, "mock", "token")
 		require.Nil(t, err)
 
-		ub := newUserBuilder(c0)
+		ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
 
 		// Page 0, limit 100: total is capped to 1000, next token expected (100 < 1000).
 		pToken := &pagination.Token{Token: "", Size: 100}
@@ -90,7 +98,7 @@ func TestUsersList(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		c := newUserBuilder(percipioClient)
+		c := newUserBuilder(percipioClient, false, false, &resourceFilter{}, userAttributeMapping{})
 
 		resources := make([]*v2.Resource, 0)
 		pToken := pagination.Token{
@@ -122,3 +130,602 @@ func TestUsersList(t *testing.T) {
 		require.NotEmpty(t, resources[0].Id)
 	})
 }
+
+// userSearchServer serves the user search of a tenant like Auth0 does: users are
+// sorted by created_at or updated_at, in an arbitrary but stable order among those
+// sharing one, or by user ID, and a search returns at most
+// Auth0UserSearchMaxResults of them.
+type userSearchServer struct {
+	mu    sync.Mutex
+	users []client2.User
+}
+
+var userDateQuery = regexp.MustCompile(`^(created_at|updated_at):\[(\S+) TO (\S+)](?: AND user_id:\{"(\S+)" TO \*])?$`)
+
+func (s *userSearchServer) add(users ...client2.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, users...)
+	slices.SortStableFunc(s.users, func(a, b client2.User) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+}
+
+func (s *userSearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/oauth/token" {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "mock-token",
+			"expires_in":   86400,
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	if (page+1)*perPage > client2.Auth0UserSearchMaxResults {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"statusCode":400,"error":"Bad Request","message":"You can only page through the first 1000 records."}`))
+		return
+	}
+
+	match := userDateQuery.FindStringSubmatch(query.Get("q"))
+	if match == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	date := func(user client2.User) time.Time {
+		if match[1] == client2.UsersFieldUpdatedAt {
+			return user.UpdatedAt
+		}
+		return user.CreatedAt
+	}
+	until, _ := time.Parse(time.RFC3339Nano, match[3])
+
+	s.mu.Lock()
+	var found []client2.User
+	for _, user := range s.users {
+		if match[2] != "*" {
+			since, _ := time.Parse(time.RFC3339Nano, match[2])
+			if date(user).Before(since) {
+				continue
+			}
+		}
+		if date(user).After(until) || match[4] != "" && user.UserId <= match[4] {
+			continue
+		}
+		found = append(found, user)
+	}
+	s.mu.Unlock()
+	switch query.Get("sort") {
+	case client2.UsersSortUserId:
+		slices.SortFunc(found, func(a, b client2.User) int {
+			return strings.Compare(a.UserId, b.UserId)
+		})
+	case client2.UsersFieldUpdatedAt + ":1":
+		slices.SortStableFunc(found, func(a, b client2.User) int {
+			return a.UpdatedAt.Compare(b.UpdatedAt)
+		})
+	}
+
+	start := min(page*perPage, len(found))
+	end := min(start+perPage, len(found))
+	_ = json.NewEncoder(w).Encode(client2.UsersResponse{
+		PaginatedResponse: client2.PaginatedResponse{Start: start, Limit: perPage, Total: len(found)},
+		Length:            end - start,
+		Users:             found[start:end],
+	})
+}
+
+// collidingUsers returns users created at only a few distinct dates, in a random
+// order, so that many of them share a created_at at window and page boundaries.
+func collidingUsers(count int, groupSizes []int) []client2.User {
+	random := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // The test only needs a reproducible order.
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	users := make([]client2.User, 0, count)
+	for i := 0; len(users) < count; i++ {
+		createdAt := base.Add(time.Duration(i) * time.Millisecond)
+		for range min(groupSizes[i%len(groupSizes)], count-len(users)) {
+			users = append(users, client2.User{
+				UserId:    fmt.Sprintf("auth0|%06d", len(users)),
+				CreatedAt: createdAt,
+			})
+		}
+	}
+	random.Shuffle(len(users), func(i, j int) {
+		users[i], users[j] = users[j], users[i]
+	})
+
+	return users
+}
+
+// listAllUsers pages through the users like a sync does, calling onPage after
+// each page, and returns how many times each user was listed.
+func listAllUsers(ctx context.Context, t *testing.T, ub *userBuilder, size int, onPage func()) map[string]int {
+	listed := make(map[string]int)
+	pToken := &pagination.Token{Size: size}
+	for range 10000 {
+		resources, nextToken, _, err := ub.List(ctx, nil, pToken)
+		require.NoError(t, err)
+		for _, resource := range resources {
+			listed[resource.Id.Resource]++
+		}
+		if nextToken == "" {
+			return listed
+		}
+		if onPage != nil {
+			onPage()
+		}
+		pToken = &pagination.Token{Size: size, Token: nextToken}
+	}
+
+	t.Fatal("pagination did not end")
+	return nil
+}
+
+func TestUsersListEnumeratesAllUsers(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name       string
+		count      int
+		groupSizes []int
+		size       int
+	}{
+		{name: "groups straddling pages", count: 3500, groupSizes: []int{37, 150, 3, 90}, size: 100},
+		{name: "page size not dividing the search limit", count: 3500, groupSizes: []int{37, 150, 3, 90}, size: 7},
+		{name: "groups larger than a page", count: 4000, groupSizes: []int{420, 1, 650, 260}, size: 50},
+		{name: "every user at the boundary", count: 2400, groupSizes: []int{999, 2}, size: 100},
+		{name: "more users at one created_at than the search limit", count: 6000, groupSizes: []int{2500, 1, 1000}, size: 100},
+		{name: "page size not dividing a tied window", count: 4500, groupSizes: []int{1, 2200}, size: 7},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			search := &userSearchServer{}
+			users := collidingUsers(tc.count, tc.groupSizes)
+			search.add(users...)
+			server := httptest.NewServer(search)
+			defer server.Close()
+
+			c0, err := client2.New(ctx, server.URL, "mock", "token")
+			require.NoError(t, err)
+
+			listed := listAllUsers(ctx, t, newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{}), tc.size, nil)
+			require.Len(t, listed, len(users))
+			for _, user := range users {
+				require.Equal(t, 1, listed[user.UserId], "user %s", user.UserId)
+			}
+		})
+	}
+
+	t.Run("users created during the sync", func(t *testing.T) {
+		search := &userSearchServer{}
+		users := collidingUsers(2500, []int{120, 7, 300})
+		search.add(users...)
+		server := httptest.NewServer(search)
+		defer server.Close()
+
+		c0, err := client2.New(ctx, server.URL, "mock", "token")
+		require.NoError(t, err)
+
+		created := 0
+		listed := listAllUsers(ctx, t, newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{}), 100, func() {
+			// Users created after the sync started are left to the next sync.
+			search.add(client2.User{UserId: fmt.Sprintf("auth0|new%d", created), CreatedAt: time.Now()})
+			created++
+		})
+		require.Len(t, listed, len(users))
+		for _, user := range users {
+			require.Equal(t, 1, listed[user.UserId], "user %s", user.UserId)
+		}
+	})
+}
+
+func TestUsersListIncremental(t *testing.T) {
+	ctx := context.Background()
+
+	// The users are updated at only a few distinct dates, one of them shared by
+	// more users than the search limit.
+	users := collidingUsers(3000, []int{1200, 40, 7})
+	for i := range users {
+		users[i].UpdatedAt = users[i].CreatedAt
+		users[i].CreatedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Users updated before the high-water mark are left out.
+	stale := client2.User{UserId: "auth0|stale", UpdatedAt: since.Add(-time.Millisecond)}
+
+	search := &userSearchServer{}
+	search.add(append([]client2.User{stale}, users...)...)
+	server := httptest.NewServer(search)
+	defer server.Close()
+
+	c0, err := client2.New(ctx, server.URL, "mock", "token")
+	require.NoError(t, err)
+	ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+	// The window ends when its first page is fetched.
+	token, err := client2.NewUpdatedUsersToken(since)
+	require.NoError(t, err)
+	until := time.Now().UTC().Add(time.Hour)
+
+	listed := make(map[string]int)
+	pToken := &pagination.Token{Size: 100, Token: token}
+	for range 1000 {
+		resources, nextToken, _, err := ub.List(ctx, nil, pToken)
+		require.NoError(t, err)
+		for _, resource := range resources {
+			listed[resource.Id.Resource]++
+		}
+		if nextToken == "" {
+			break
+		}
+
+		// A user listed earlier is updated again, which moves it past the end
+		// of the window without shifting the users not listed yet.
+		search.mu.Lock()
+		for i := range search.users {
+			if listed[search.users[i].UserId] > 0 && search.users[i].UpdatedAt.Before(until) {
+				search.users[i].UpdatedAt = until.Add(time.Minute)
+				break
+			}
+		}
+		search.mu.Unlock()
+
+		pToken = &pagination.Token{Size: 100, Token: nextToken}
+	}
+
+	require.Len(t, listed, len(users))
+	for _, user := range users {
+		require.Equal(t, 1, listed[user.UserId], "user %s", user.UserId)
+	}
+}
+
+func TestUserResourceMapping(t *testing.T) {
+	mapping := userAttributeMapping{
+		employeeIdPath: "hr.employee_id",
+		managerPath:    "hr.manager",
+		departmentPath: "department",
+	}
+
+	t.Run("unverified email", func(t *testing.T) {
+		resource, err := userResource(client2.User{
+			UserId:      "auth0|1",
+			Email:       "jane@example.com",
+			Username:    "jane",
+			GivenName:   "Jane",
+			FamilyName:  "Doe",
+			Name:        "jane@example.com",
+			Multifactor: []string{"guardian"},
+			AppMetadata: map[string]interface{}{
+				"hr":         map[string]interface{}{"employee_id": float64(1234), "manager": "john@example.com"},
+				"department": "Engineering",
+			},
+		}, nil, mapping)
+		require.NoError(t, err)
+
+		trait, err := resourceSdk.GetUserTrait(resource)
+		require.NoError(t, err)
+		require.Equal(t, "jane@example.com", trait.GetLogin())
+		require.Equal(t, []string{"jane"}, trait.GetLoginAliases())
+		require.Len(t, trait.GetEmails(), 1)
+		require.False(t, trait.GetEmails()[0].GetIsPrimary())
+		require.Equal(t, []string{"1234"}, trait.GetEmployeeIds())
+		require.Equal(t, "Jane", trait.GetStructuredName().GetGivenName())
+		require.True(t, trait.GetMfaStatus().GetMfaEnabled())
+
+		profile := resourceSdk.GetProfile(resource)
+		require.Equal(t, "Doe", profile.GetFields()["last_name"].GetStringValue())
+		require.Equal(t, "john@example.com", profile.GetFields()["manager"].GetStringValue())
+		require.Equal(t, "Engineering", profile.GetFields()["department"].GetStringValue())
+	})
+
+	t.Run("no email", func(t *testing.T) {
+		resource, err := userResource(client2.User{UserId: "sms|1", PhoneNumber: "+15555550100"}, nil, mapping)
+		require.NoError(t, err)
+
+		trait, err := resourceSdk.GetUserTrait(resource)
+		require.NoError(t, err)
+		require.Equal(t, "+15555550100", trait.GetLogin())
+		require.Empty(t, trait.GetEmails())
+		require.Empty(t, trait.GetEmployeeIds())
+		require.Equal(t, "+15555550100", resource.DisplayName)
+	})
+
+	t.Run("no login", func(t *testing.T) {
+		resource, err := userResource(client2.User{UserId: "oauth2|corp|1"}, nil, mapping)
+		require.NoError(t, err)
+
+		trait, err := resourceSdk.GetUserTrait(resource)
+		require.NoError(t, err)
+		require.Equal(t, "oauth2|corp|1", trait.GetLogin())
+		require.Empty(t, resourceSdk.GetProfile(resource).GetFields()["manager"].GetStringValue())
+	})
+}
+
+func TestUserResourceConnections(t *testing.T) {
+	resource, err := userResource(client2.User{
+		UserId: "auth0|1",
+		Email:  "jane@example.com",
+		Identities: []client2.UserIdentities{
+			{Connection: "Username-Password-Authentication"},
+			{Connection: "google-oauth2"},
+		},
+	}, nil, userAttributeMapping{})
+	require.NoError(t, err)
+
+	// Users are top-level resources, whose connections are grants.
+	require.Nil(t, resource.ParentResourceId)
+	profile := resourceSdk.GetProfile(resource)
+	require.Equal(t, "Username-Password-Authentication", profile.GetFields()["connection"].GetStringValue())
+
+	var connections []string
+	for _, grant := range connectionUserGrants(resource, &resourceFilter{}) {
+		connections = append(connections, grant.Entitlement.Resource.Id.Resource)
+	}
+	require.Equal(t, []string{"Username-Password-Authentication", "google-oauth2"}, connections)
+}
+
+// userAdminServer serves the Management API endpoints used to administer users,
+// keeping the users it creates.
+type userAdminServer struct {
+	mu          sync.Mutex
+	users       []client2.User
+	connections []client2.Connection
+	passwords   map[string]string
+	tickets     []string
+}
+
+func (s *userAdminServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/oauth/token":
+		_ = json.NewEncoder(w).Encode(client2.AuthResponse{AccessToken: "mock-token", ExpiresIn: 86400})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/users":
+		var request client2.CreateUserRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		for _, user := range s.users {
+			if user.Email == request.Email && user.Identities[0].Connection == request.Connection {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(client2.APIError{
+					StatusCode: http.StatusConflict,
+					Err:        "Conflict",
+					Message:    "The user already exists.",
+				})
+				return
+			}
+		}
+		user := client2.User{
+			UserId:     fmt.Sprintf("auth0|%d", len(s.users)+1),
+			Email:      request.Email,
+			Name:       request.Name,
+			Identities: []client2.UserIdentities{{Connection: request.Connection, Provider: "auth0"}},
+		}
+		s.users = append(s.users, user)
+		s.passwords[user.UserId] = request.Password
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(user)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/users-by-email":
+		found := []client2.User{}
+		for _, user := range s.users {
+			if user.Email == r.URL.Query().Get("email") {
+				found = append(found, user)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(found)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/tickets/password-change":
+		var request struct {
+			UserId string `json:"user_id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		s.tickets = append(s.tickets, request.UserId)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(client2.PasswordChangeTicket{Ticket: "https://tenant.auth0.com/lo/reset?ticket=" + request.UserId})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v2/users/"):
+		user := s.user(strings.TrimPrefix(r.URL.Path, "/api/v2/users/"))
+		if user == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(user)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/connections":
+		found := []client2.Connection{}
+		for _, connection := range s.connections {
+			if connection.Name == r.URL.Query().Get("name") {
+				found = append(found, connection)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(found)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/api/v2/users/"):
+		user := s.user(strings.TrimPrefix(r.URL.Path, "/api/v2/users/"))
+		if user == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var update struct {
+			Blocked    *bool  `json:"blocked"`
+			Password   string `json:"password"`
+			Connection string `json:"connection"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		if update.Blocked != nil {
+			user.Blocked = *update.Blocked
+		}
+		if update.Password != "" {
+			// Auth0 needs the database connection to change a password.
+			if !slices.ContainsFunc(user.Identities, func(identity client2.UserIdentities) bool {
+				return identity.Connection == update.Connection && identity.Provider == "auth0"
+			}) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			s.passwords[user.UserId] = update.Password
+		}
+		_ = json.NewEncoder(w).Encode(user)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v2/users/"):
+		userId := strings.TrimPrefix(r.URL.Path, "/api/v2/users/")
+		s.users = slices.DeleteFunc(s.users, func(user client2.User) bool { return user.UserId == userId })
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *userAdminServer) user(userId string) *client2.User {
+	for i := range s.users {
+		if s.users[i].UserId == userId {
+			return &s.users[i]
+		}
+	}
+	return nil
+}
+
+func newUserAdminServer(t *testing.T) (*userAdminServer, *client2.Client) {
+	tenant := &userAdminServer{passwords: make(map[string]string)}
+	server := httptest.NewServer(tenant)
+	t.Cleanup(server.Close)
+
+	c0, err := client2.New(context.Background(), server.URL, "mock", "token")
+	require.NoError(t, err)
+	return tenant, c0
+}
+
+func TestUserCreateAccount(t *testing.T) {
+	ctx := context.Background()
+
+	accountInfo := func(email string) *v2.AccountInfo {
+		profile, err := structpb.NewStruct(map[string]any{"email": email, "name": "Jane Doe", "connection": "employees"})
+		require.NoError(t, err)
+		return &v2.AccountInfo{Profile: profile}
+	}
+	randomPassword := &v2.LocalCredentialOptions{
+		Options: &v2.LocalCredentialOptions_RandomPassword_{
+			RandomPassword: &v2.LocalCredentialOptions_RandomPassword{Length: 16},
+		},
+	}
+
+	t.Run("random password", func(t *testing.T) {
+		tenant, c0 := newUserAdminServer(t)
+		ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+		response, plaintexts, _, err := ub.CreateAccount(ctx, accountInfo("jane@example.com"), randomPassword)
+		require.NoError(t, err)
+
+		result, ok := response.(*v2.CreateAccountResponse_SuccessResult)
+		require.True(t, ok)
+		require.Equal(t, "auth0|1", result.Resource.Id.Resource)
+		require.Len(t, plaintexts, 1)
+		require.Equal(t, "password", plaintexts[0].Name)
+		require.Len(t, plaintexts[0].Bytes, 16)
+		require.Equal(t, string(plaintexts[0].Bytes), tenant.passwords["auth0|1"])
+		require.Empty(t, tenant.tickets)
+	})
+
+	t.Run("invitation", func(t *testing.T) {
+		tenant, c0 := newUserAdminServer(t)
+		ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+		noPassword := &v2.LocalCredentialOptions{
+			Options: &v2.LocalCredentialOptions_NoPassword_{NoPassword: &v2.LocalCredentialOptions_NoPassword{}},
+		}
+		response, plaintexts, _, err := ub.CreateAccount(ctx, accountInfo("jane@example.com"), noPassword)
+		require.NoError(t, err)
+
+		result, ok := response.(*v2.CreateAccountResponse_ActionRequiredResult)
+		require.True(t, ok)
+		require.Equal(t, "auth0|1", result.Resource.Id.Resource)
+		require.Len(t, plaintexts, 1)
+		require.Equal(t, "password_change_ticket", plaintexts[0].Name)
+		require.Equal(t, "https://tenant.auth0.com/lo/reset?ticket=auth0|1", string(plaintexts[0].Bytes))
+		// The user still gets a throwaway password, which is never returned.
+		require.Len(t, tenant.passwords["auth0|1"], invitationPasswordLength)
+		require.Equal(t, []string{"auth0|1"}, tenant.tickets)
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		tenant, c0 := newUserAdminServer(t)
+		ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+		_, _, _, err := ub.CreateAccount(ctx, accountInfo("jane@example.com"), randomPassword)
+		require.NoError(t, err)
+
+		response, plaintexts, _, err := ub.CreateAccount(ctx, accountInfo("jane@example.com"), randomPassword)
+		require.NoError(t, err)
+
+		result, ok := response.(*v2.CreateAccountResponse_AlreadyExistsResult)
+		require.True(t, ok)
+		require.Equal(t, "auth0|1", result.Resource.Id.Resource)
+		require.Empty(t, plaintexts)
+		require.Len(t, tenant.users, 1)
+	})
+
+	t.Run("email is required", func(t *testing.T) {
+		_, c0 := newUserAdminServer(t)
+		ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+		_, _, _, err := ub.CreateAccount(ctx, &v2.AccountInfo{}, randomPassword)
+		require.Error(t, err)
+	})
+}
+
+func TestUserRotate(t *testing.T) {
+	ctx := context.Background()
+
+	tenant, c0 := newUserAdminServer(t)
+	tenant.users = []client2.User{
+		{
+			UserId: "auth0|1",
+			Identities: []client2.UserIdentities{
+				{Connection: "google-oauth2", Provider: "google-oauth2", IsSocial: true},
+				{Connection: "employees", Provider: "auth0"},
+			},
+		},
+		{
+			UserId:     "google-oauth2|2",
+			Identities: []client2.UserIdentities{{Connection: "google-oauth2", Provider: "google-oauth2", IsSocial: true}},
+		},
+	}
+	tenant.connections = []client2.Connection{
+		{Id: "con_1", Name: "employees", Options: &client2.ConnectionOptions{PasswordPolicy: "excellent"}},
+	}
+	ub := newUserBuilder(c0, false, false, &resourceFilter{}, userAttributeMapping{})
+
+	randomPassword := &v2.LocalCredentialOptions{
+		Options: &v2.LocalCredentialOptions_RandomPassword_{
+			RandomPassword: &v2.LocalCredentialOptions_RandomPassword{Length: 4},
+		},
+	}
+
+	t.Run("database user", func(t *testing.T) {
+		plaintexts, _, err := ub.Rotate(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|1"}, randomPassword)
+		require.NoError(t, err)
+		require.Len(t, plaintexts, 1)
+
+		// The excellent policy raises the requested length and needs a special character.
+		password := string(plaintexts[0].Bytes)
+		require.Equal(t, tenant.passwords["auth0|1"], password)
+		require.Len(t, password, 10)
+		require.True(t, strings.ContainsAny(password, passwordSpecialCharacters))
+		require.LessOrEqual(t, maxRepeatedCharacters(password), 2)
+	})
+
+	t.Run("social user", func(t *testing.T) {
+		_, _, err := ub.Rotate(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "google-oauth2|2"}, randomPassword)
+		require.Error(t, err)
+		require.NotContains(t, tenant.passwords, "google-oauth2|2")
+	})
+
+	t.Run("plaintext password", func(t *testing.T) {
+		plaintext := &v2.LocalCredentialOptions{
+			Options: &v2.LocalCredentialOptions_PlaintextPassword_{
+				PlaintextPassword: &v2.LocalCredentialOptions_PlaintextPassword{PlaintextPassword: "hunter2"},
+			},
+		}
+		_, _, err := ub.Rotate(ctx, &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "auth0|1"}, plaintext)
+		require.Error(t, err)
+	})
+}